@@ -1,9 +1,33 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
+	"food-review/pkg/config"
 	"food-review/pkg/http"
 )
 
 func main() {
-	http.StartServer()
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+			os.Exit(2)
+		}
+		os.Exit(migrate(cfg, args[1:], os.Stdout))
+	}
+
+	if err := http.StartServer(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
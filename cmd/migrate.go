@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+
+	"food-review/pkg/config"
+	"food-review/pkg/db"
+)
+
+const migrateUsage = `usage: food-review [flags] migrate up [review|dictionary|postgres|ratelimit]
+       food-review [flags] migrate down review|dictionary|postgres|ratelimit
+       food-review [flags] migrate status [review|dictionary|postgres|ratelimit]`
+
+type migrationTarget struct {
+	name       string
+	open       func() (*sql.DB, error)
+	migrations []db.Migration
+}
+
+// migrationTargets lists the databases of the configured backend, followed by
+// the rate limit database when there is one. Postgres holds everything else
+// in one database.
+func migrationTargets(cfg config.Config) []migrationTarget {
+	targets := storeMigrationTargets(cfg)
+	if cfg.RateLimitDB != "" {
+		targets = append(targets, migrationTarget{
+			name: "ratelimit",
+			open: func() (*sql.DB, error) {
+				rateLimitDB := db.NewRateLimitDB(cfg.RateLimitDB)
+				err := rateLimitDB.Open()
+				return rateLimitDB.GetDB(), err
+			},
+			migrations: db.RateLimitMigrations,
+		})
+	}
+	return targets
+}
+
+func storeMigrationTargets(cfg config.Config) []migrationTarget {
+	if cfg.UsePostgres() {
+		return []migrationTarget{
+			{
+				name: "postgres",
+				open: func() (*sql.DB, error) {
+					postgresDB := db.NewPostgresDB(cfg.PostgresDSN)
+					err := postgresDB.Open()
+					return postgresDB.GetDB(), err
+				},
+				migrations: db.PostgresMigrations,
+			},
+		}
+	}
+
+	return []migrationTarget{
+		{
+			name: "review",
+			open: func() (*sql.DB, error) {
+				reviewDB := db.NewReviewDB(cfg.ReviewDB)
+				err := reviewDB.Open()
+				return reviewDB.GetDB(), err
+			},
+			migrations: db.ReviewMigrations,
+		},
+		{
+			name: "dictionary",
+			open: func() (*sql.DB, error) {
+				dictionaryDB := db.NewDictionaryDB(cfg.DictionaryDB)
+				err := dictionaryDB.Open()
+				return dictionaryDB.GetDB(), err
+			},
+			migrations: db.DictionaryMigrations,
+		},
+	}
+}
+
+// migrate runs the migrate subcommand and returns the process exit code.
+// Reverting is limited to one named database at a time since it can drop
+// data.
+func migrate(cfg config.Config, args []string, out io.Writer) int {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(out, migrateUsage)
+		return 2
+	}
+	command := args[0]
+
+	targets := migrationTargets(cfg)
+	if len(args) == 2 {
+		var selected []migrationTarget
+		for _, target := range targets {
+			if target.name == args[1] {
+				selected = append(selected, target)
+			}
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(out, migrateUsage)
+			return 2
+		}
+		targets = selected
+	} else if command == "down" {
+		fmt.Fprintln(out, migrateUsage)
+		return 2
+	}
+
+	for _, target := range targets {
+		database, err := target.open()
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", target.name, err)
+			return 1
+		}
+
+		err = runMigrate(command, target, database, out)
+		database.Close()
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", target.name, err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func runMigrate(command string, target migrationTarget, database *sql.DB, out io.Writer) error {
+	switch command {
+	case "up":
+		applied, err := db.MigrateUp(database, target.migrations)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "%s: applied %04d_%s\n", target.name, migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintf(out, "%s: up to date\n", target.name)
+		}
+		return err
+
+	case "down":
+		reverted, err := db.MigrateDown(database, target.migrations)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Fprintf(out, "%s: nothing to revert\n", target.name)
+		} else {
+			fmt.Fprintf(out, "%s: reverted %04d_%s\n", target.name, reverted.Version, reverted.Name)
+		}
+		return nil
+
+	case "status":
+		statuses, err := db.MigrationStatuses(database, target.migrations)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%s: %04d_%s %s\n", target.name, status.Version, status.Name, applied)
+		}
+		return db.CheckSchema(database, target.migrations)
+
+	default:
+		fmt.Fprintln(out, migrateUsage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
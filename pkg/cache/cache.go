@@ -0,0 +1,131 @@
+// Package cache keeps rendered responses in memory, evicting the least
+// recently used once full. Entries expire after a TTL and are all dropped
+// together when the data behind them changes.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Entry is a rendered response.
+type Entry struct {
+	ContentType string
+	Body        []byte
+	ETag        string
+	// Modified is when the data the page shows last changed.
+	Modified time.Time
+	stored   time.Time
+}
+
+type item struct {
+	key   string
+	entry *Entry
+}
+
+type Cache struct {
+	// Now timestamps entries; tests replace it.
+	Now func() time.Time
+
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	items      map[string]*list.Element
+	// order holds the items from most to least recently used.
+	order      *list.List
+	generation uint64
+	modified   time.Time
+}
+
+// New returns a cache of up to maxEntries entries, each kept for at most ttl.
+func New(maxEntries int, ttl time.Duration) *Cache {
+	return &Cache{
+		Now:        time.Now,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		items:      map[string]*list.Element{},
+		order:      list.New(),
+		modified:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Get returns the live entry for key.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*item).entry
+	if c.Now().Sub(entry.stored) >= c.ttl {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry, true
+}
+
+// Generation identifies the data the cache currently reflects. Take it
+// before reading the data an entry is rendered from and pass it to Put.
+func (c *Cache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Put stores entry under key unless the cache was invalidated since
+// generation was taken, in which case the entry may already be stale.
+func (c *Cache) Put(key string, entry *Entry, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	entry.stored = c.Now()
+	if element, ok := c.items[key]; ok {
+		element.Value.(*item).entry = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&item{key: key, entry: entry})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate drops every entry, after a write changed what they show.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = map[string]*list.Element{}
+	c.order.Init()
+	c.modified = c.Now().UTC().Truncate(time.Second)
+}
+
+// Modified is when the data last changed, to the second as HTTP dates go:
+// the last invalidation, or when the cache was created.
+func (c *Cache) Modified() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.modified
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*item).key)
+}
@@ -0,0 +1,67 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/cache"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	newCache := func() *cache.Cache {
+		c := cache.New(2, time.Minute)
+		c.Now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("Evicts Least Recently Used", func(t *testing.T) {
+		c := newCache()
+		c.Put("/reviews?", &cache.Entry{Body: []byte("all")}, c.Generation())
+		c.Put("/reviews/1?", &cache.Entry{Body: []byte("one")}, c.Generation())
+		c.Get("/reviews?")
+		c.Put("/reviews/2?", &cache.Entry{Body: []byte("two")}, c.Generation())
+
+		_, ok := c.Get("/reviews/1?")
+		assert.False(t, ok)
+		entry, ok := c.Get("/reviews?")
+		if assert.True(t, ok) {
+			assert.Equal(t, "all", string(entry.Body))
+		}
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("Expires", func(t *testing.T) {
+		c := newCache()
+		c.Put("/stats?", &cache.Entry{}, c.Generation())
+
+		now = now.Add(time.Minute)
+		_, ok := c.Get("/stats?")
+		assert.False(t, ok)
+		assert.Zero(t, c.Len())
+	})
+
+	t.Run("Invalidate", func(t *testing.T) {
+		c := newCache()
+		c.Put("/reviews?", &cache.Entry{}, c.Generation())
+
+		now = now.Add(time.Hour)
+		c.Invalidate()
+
+		_, ok := c.Get("/reviews?")
+		assert.False(t, ok)
+		assert.Equal(t, now, c.Modified())
+	})
+
+	t.Run("Stale Generation", func(t *testing.T) {
+		c := newCache()
+		generation := c.Generation()
+		c.Invalidate()
+
+		c.Put("/reviews?", &cache.Entry{}, generation)
+		_, ok := c.Get("/reviews?")
+		assert.False(t, ok, "a page read before a write isn't cached after it")
+	})
+}
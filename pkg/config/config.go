@@ -0,0 +1,224 @@
+// Package config gathers the server settings. Each value comes from, in
+// increasing precedence, the built-in default, the YAML config file, a
+// FOOD_REVIEW_* environment variable and a command-line flag.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"food-review/pkg/logging"
+)
+
+// DefaultPath is read when neither -config nor FOOD_REVIEW_CONFIG names a
+// file. Unlike an explicitly named file, it may be missing.
+const DefaultPath = "config/food-review.yaml"
+
+const envPrefix = "FOOD_REVIEW_"
+
+type Config struct {
+	Addr         string `yaml:"addr"`
+	ReviewDB     string `yaml:"review_db"`
+	DictionaryDB string `yaml:"dictionary_db"`
+	// PostgresDSN, when set, stores reviews and the dictionary in Postgres
+	// instead of the SQLite files.
+	PostgresDSN string `yaml:"postgres_dsn"`
+	// AssetsDir, when set, is a directory laid out like pkg/web that the
+	// templates and static files are read from instead of the binary. The
+	// templates are reloaded when they change, which suits development.
+	AssetsDir string `yaml:"assets_dir"`
+	// SpamRules, Profanity and RateLimits are JSON files overriding the spam
+	// rules, the profanity filter and the per-route request budgets built
+	// into the binary. The built-in ones are used when they are empty.
+	SpamRules  string `yaml:"spam_rules"`
+	Profanity  string `yaml:"profanity"`
+	RateLimits string `yaml:"rate_limits"`
+	// RateLimitDB, when set, is a SQLite file the rate limit buckets are kept
+	// in so they survive a restart. They are kept in memory otherwise.
+	RateLimitDB string `yaml:"rate_limit_db"`
+	// LogLevel is the least severe level logged: debug, info, warn or error.
+	LogLevel string `yaml:"log_level"`
+	// TraceOutput is where spans are written as OTLP/JSON lines: "stdout" or
+	// a file path. Tracing is off when it is empty.
+	TraceOutput string `yaml:"trace_output"`
+	// ModeratorKeys lists who may use the moderation and admin pages, as
+	// comma separated name:key pairs. Nobody may when it is empty.
+	ModeratorKeys string `yaml:"moderator_keys"`
+}
+
+func Default() Config {
+	return Config{
+		Addr:         ":5555",
+		ReviewDB:     "./db/review.db",
+		DictionaryDB: "./db/dictionary.db",
+		LogLevel:     "info",
+	}
+}
+
+func (c Config) UsePostgres() bool {
+	return c.PostgresDSN != ""
+}
+
+// Moderators parses ModeratorKeys into each moderator's key by name.
+func (c Config) Moderators() (map[string]string, error) {
+	moderators := map[string]string{}
+	if strings.TrimSpace(c.ModeratorKeys) == "" {
+		return moderators, nil
+	}
+	for i, pair := range strings.Split(c.ModeratorKeys, ",") {
+		// Errors don't quote the entry, which may hold a key.
+		name, key, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || name == "" || key == "" {
+			return nil, fmt.Errorf("entry %d is not name:key", i+1)
+		}
+		if _, taken := moderators[name]; taken {
+			return nil, fmt.Errorf("moderator %q is listed twice", name)
+		}
+		moderators[name] = key
+	}
+	return moderators, nil
+}
+
+// setting ties a Config field to its flag. The environment variable is the
+// flag name in upper case with dashes as underscores, after envPrefix.
+type setting struct {
+	name  string
+	usage string
+	field func(c *Config) *string
+}
+
+var settings = []setting{
+	{"addr", "address to listen on", func(c *Config) *string { return &c.Addr }},
+	{"review-db", "SQLite file for reviews", func(c *Config) *string { return &c.ReviewDB }},
+	{"dictionary-db", "SQLite file for the dictionary", func(c *Config) *string { return &c.DictionaryDB }},
+	{"postgres-dsn", "Postgres DSN; replaces the SQLite files when set", func(c *Config) *string { return &c.PostgresDSN }},
+	{"assets-dir", "read templates and static files from this directory and reload templates on change", func(c *Config) *string { return &c.AssetsDir }},
+	{"spam-rules", "spam rules JSON file", func(c *Config) *string { return &c.SpamRules }},
+	{"profanity", "profanity filter JSON file", func(c *Config) *string { return &c.Profanity }},
+	{"rate-limits", "rate limit budgets JSON file", func(c *Config) *string { return &c.RateLimits }},
+	{"rate-limit-db", "SQLite file for rate limit buckets; kept in memory when empty", func(c *Config) *string { return &c.RateLimitDB }},
+	{"log-level", "least severe level logged: debug, info, warn or error", func(c *Config) *string { return &c.LogLevel }},
+	{"trace-output", `"stdout" or a file for OTLP/JSON spans; tracing is off when empty`, func(c *Config) *string { return &c.TraceOutput }},
+	{"moderator-keys", "comma separated name:key pairs allowed on the moderation and admin pages", func(c *Config) *string { return &c.ModeratorKeys }},
+}
+
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load builds the config from args (without the program name) and the
+// environment read through getenv. It returns the arguments left after the
+// flags, such as a subcommand.
+func Load(args []string, getenv func(string) string) (Config, []string, error) {
+	flags := flag.NewFlagSet("food-review", flag.ContinueOnError)
+	path := flags.String("config", "", "YAML config file (default "+DefaultPath+")")
+	values := make([]*string, len(settings))
+	for i, s := range settings {
+		values[i] = flags.String(s.name, "", s.usage+" (env "+envName(s.name)+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	config := Default()
+
+	required := true
+	if !set["config"] {
+		*path = getenv(envName("config"))
+	}
+	if *path == "" {
+		*path = DefaultPath
+		required = false
+	}
+	if err := config.readFile(*path, required); err != nil {
+		return Config{}, nil, err
+	}
+
+	for i, s := range settings {
+		if value := getenv(envName(s.name)); value != "" {
+			*s.field(&config) = value
+		}
+		if set[s.name] {
+			*s.field(&config) = *values[i]
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return config, flags.Args(), nil
+}
+
+// readFile overlays the values set in the YAML file at path. Unknown keys are
+// rejected so a misspelt setting doesn't silently keep its default.
+func (c *Config) readFile(path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(c)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate reports the first setting that can't work.
+func (c Config) Validate() error {
+	_, port, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid addr %q: %w", c.Addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid addr %q: bad port", c.Addr)
+	}
+
+	if c.UsePostgres() {
+		if scheme, _, found := strings.Cut(c.PostgresDSN, "://"); found && scheme != "postgres" && scheme != "postgresql" {
+			return fmt.Errorf("invalid postgres_dsn: unsupported scheme %q", scheme)
+		}
+	} else {
+		if c.ReviewDB == "" || c.DictionaryDB == "" {
+			return errors.New("review_db and dictionary_db are required without postgres_dsn")
+		}
+		if filepath.Clean(c.ReviewDB) == filepath.Clean(c.DictionaryDB) {
+			return errors.New("review_db and dictionary_db must be different files")
+		}
+	}
+
+	if c.RateLimitDB != "" && !c.UsePostgres() {
+		for _, other := range []string{c.ReviewDB, c.DictionaryDB} {
+			if filepath.Clean(c.RateLimitDB) == filepath.Clean(other) {
+				return errors.New("rate_limit_db must not share a file with review_db or dictionary_db")
+			}
+		}
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("invalid log_level: %w", err)
+	}
+	if _, err := c.Moderators(); err != nil {
+		return fmt.Errorf("invalid moderator_keys: %w", err)
+	}
+
+	return nil
+}
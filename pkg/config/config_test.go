@@ -0,0 +1,120 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/config"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "food-review.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, args, err := config.Load([]string{"-config", writeConfig(t, "")}, env(nil))
+		if assert.NoError(t, err) {
+			assert.Equal(t, config.Default(), cfg)
+			assert.Empty(t, args)
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		path := writeConfig(t, "addr: \":6000\"\nreview_db: file.db\nassets_dir: file\n")
+		vars := env(map[string]string{
+			"FOOD_REVIEW_CONFIG":     path,
+			"FOOD_REVIEW_REVIEW_DB":  "env.db",
+			"FOOD_REVIEW_ASSETS_DIR": "env",
+		})
+
+		cfg, args, err := config.Load([]string{"-assets-dir", "flag", "migrate", "up"}, vars)
+		if assert.NoError(t, err) {
+			assert.Equal(t, ":6000", cfg.Addr)
+			assert.Equal(t, "env.db", cfg.ReviewDB)
+			assert.Equal(t, "flag", cfg.AssetsDir)
+			assert.Equal(t, config.Default().DictionaryDB, cfg.DictionaryDB)
+			assert.Equal(t, []string{"migrate", "up"}, args)
+		}
+	})
+
+	t.Run("Missing Named File", func(t *testing.T) {
+		_, _, err := config.Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown Key", func(t *testing.T) {
+		_, _, err := config.Load([]string{"-config", writeConfig(t, "adress: \":6000\"\n")}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown Flag", func(t *testing.T) {
+		_, _, err := config.Load([]string{"-port", "6000"}, env(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		change func(cfg *config.Config)
+		valid  bool
+	}{
+		"Defaults":         {func(cfg *config.Config) {}, true},
+		"Bad Port":         {func(cfg *config.Config) { cfg.Addr = ":http-ish" }, false},
+		"Missing Port":     {func(cfg *config.Config) { cfg.Addr = "localhost" }, false},
+		"Same SQLite File": {func(cfg *config.Config) { cfg.DictionaryDB = "db/../db/review.db" }, false},
+		"Postgres Without SQLite": {func(cfg *config.Config) {
+			cfg.PostgresDSN = "postgres://localhost/review"
+			cfg.ReviewDB = ""
+			cfg.DictionaryDB = ""
+		}, true},
+		"Postgres Key Value DSN": {func(cfg *config.Config) { cfg.PostgresDSN = "host=localhost dbname=review" }, true},
+		"Wrong DSN Scheme":       {func(cfg *config.Config) { cfg.PostgresDSN = "mysql://localhost/review" }, false},
+		"Rate Limit File":        {func(cfg *config.Config) { cfg.RateLimitDB = "db/ratelimit.db" }, true},
+		"Rate Limit In Review":   {func(cfg *config.Config) { cfg.RateLimitDB = "./db/review.db" }, false},
+		"Debug Logging":          {func(cfg *config.Config) { cfg.LogLevel = "DEBUG" }, true},
+		"Unknown Log Level":      {func(cfg *config.Config) { cfg.LogLevel = "verbose" }, false},
+		"Moderator Keys":         {func(cfg *config.Config) { cfg.ModeratorKeys = "somchai:k1, anna:k2" }, true},
+		"Moderator Without Key":  {func(cfg *config.Config) { cfg.ModeratorKeys = "somchai" }, false},
+		"Moderator Twice":        {func(cfg *config.Config) { cfg.ModeratorKeys = "anna:k1,anna:k2" }, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.Default()
+			tc.change(&cfg)
+			if tc.valid {
+				assert.NoError(t, cfg.Validate())
+			} else {
+				assert.Error(t, cfg.Validate())
+			}
+		})
+	}
+}
+
+func TestModerators(t *testing.T) {
+	cfg := config.Default()
+	moderators, err := cfg.Moderators()
+	if assert.NoError(t, err) {
+		assert.Empty(t, moderators)
+	}
+
+	cfg.ModeratorKeys = "somchai:k1, anna:k2:with-colon"
+	moderators, err = cfg.Moderators()
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"somchai": "k1", "anna": "k2:with-colon"}, moderators)
+	}
+
+	cfg.ModeratorKeys = "just-a-secret-key"
+	_, err = cfg.Moderators()
+	assert.NotContains(t, err.Error(), "just-a-secret-key", "keys stay out of errors")
+}
@@ -2,53 +2,51 @@ package db
 
 import (
 	"database/sql"
-	"log"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func InitDictionaryDB() *DictionaryDB {
-	driver := "sqlite3"
-	dataSource := "./db/dictionary.db"
-	initStatement := `
-		CREATE TABLE IF NOT EXISTS
-		dictionary (
-			keyword TEXT
-		)
-	`
-	db := &DictionaryDB{
-		Driver:        driver,
-		DataSource:    dataSource,
-		InitStatement: initStatement,
-	}
+// DictionaryMigrations are the dictionary database's schema migrations
+// embedded in the binary.
+var DictionaryMigrations = mustLoadMigrations("migrations/dictionary")
 
-	err := db.Init()
-	if err != nil {
-		log.Fatal(err)
+// NewDictionaryDB describes the dictionary database in the SQLite file at
+// dataSource without opening it.
+func NewDictionaryDB(dataSource string) *DictionaryDB {
+	return &DictionaryDB{
+		Driver:     "sqlite3",
+		DataSource: dataSource,
+		Migrations: DictionaryMigrations,
 	}
-
-	return db
-}
-
-type DictionaryDBOpener interface {
-	GetDB() *sql.DB
 }
 
 type DictionaryDB struct {
-	Driver        string
-	DataSource    string
-	InitStatement string
-	Database      *sql.DB
+	Driver     string
+	DataSource string
+	Migrations []Migration
+	Database   *sql.DB
 }
 
-func (db *DictionaryDB) Init() error {
+// Open connects to the database without touching its schema.
+func (db *DictionaryDB) Open() error {
 	database, err := sql.Open(db.Driver, db.DataSource)
 	if err != nil {
 		return err
 	}
 
 	db.Database = database
-	_, err = db.Database.Exec(db.InitStatement)
+	return nil
+}
+
+// Init opens the database and applies any pending migrations. It refuses a
+// schema newer than the known migrations.
+func (db *DictionaryDB) Init() error {
+	err := db.Open()
+	if err != nil {
+		return err
+	}
+
+	_, err = MigrateUp(db.Database, db.Migrations)
 	if err != nil {
 		return err
 	}
@@ -59,3 +57,12 @@ func (db *DictionaryDB) Init() error {
 func (db *DictionaryDB) GetDB() *sql.DB {
 	return db.Database
 }
+
+// Close releases the connection pool. It does nothing if the database was
+// never opened.
+func (db *DictionaryDB) Close() error {
+	if db.Database == nil {
+		return nil
+	}
+	return db.Database.Close()
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+var (
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+	ErrSchemaBehind = errors.New("database schema has pending migrations")
+)
+
+// The statements here run on both SQLite and Postgres, so they stick to $n
+// placeholders and types both understand.
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS
+	schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// Migration is one versioned schema change, read from a pair of files named
+// like 0002_moderation.up.sql and 0002_moderation.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads the migrations in dir, ordered by version. Every
+// version needs both an up and a down file.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		versionStr, migrationName, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !found || err != nil || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("%s: expected <version>_<name>.up.sql or .down.sql", name)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = migration
+		} else if migration.Name != migrationName {
+			return nil, fmt.Errorf("%s: version %d is already %s", name, version, migration.Name)
+		}
+		if direction == ".up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func mustLoadMigrations(dir string) []Migration {
+	migrations, err := LoadMigrations(migrationsFS, dir)
+	if err != nil {
+		panic(err)
+	}
+	return migrations
+}
+
+// SchemaVersion is the latest migration applied to database, or 0.
+func SchemaVersion(database *sql.DB) (int, error) {
+	_, err := database.Exec(createMigrationsTable)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = database.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// CheckSchema fails with ErrSchemaTooNew when database has migrations applied
+// that are not in migrations, e.g. after rolling back to an older binary.
+func CheckSchema(database *sql.DB, migrations []Migration) error {
+	version, err := SchemaVersion(database)
+	if err != nil {
+		return err
+	}
+
+	latest := LatestVersion(migrations)
+	if version > latest {
+		return fmt.Errorf("%w: at version %d, latest known is %d", ErrSchemaTooNew, version, latest)
+	}
+
+	return nil
+}
+
+// CheckSchemaCurrent is CheckSchema that also fails with ErrSchemaBehind
+// until every migration has been applied.
+func CheckSchemaCurrent(database *sql.DB, migrations []Migration) error {
+	if err := CheckSchema(database, migrations); err != nil {
+		return err
+	}
+
+	version, err := SchemaVersion(database)
+	if err != nil {
+		return err
+	}
+	if latest := LatestVersion(migrations); version < latest {
+		return fmt.Errorf("%w: at version %d, latest is %d", ErrSchemaBehind, version, latest)
+	}
+
+	return nil
+}
+
+// LatestVersion is the version of the last of migrations, or 0 when there are
+// none.
+func LatestVersion(migrations []Migration) int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrateUp applies every pending migration in order, each in its own
+// transaction, and returns the ones applied.
+func MigrateUp(database *sql.DB, migrations []Migration) ([]Migration, error) {
+	var applied []Migration
+
+	if err := CheckSchema(database, migrations); err != nil {
+		return nil, err
+	}
+	version, err := SchemaVersion(database)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		err := inTx(database, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the latest applied migration and returns it, or nil
+// when nothing is applied.
+func MigrateDown(database *sql.DB, migrations []Migration) (*Migration, error) {
+	if err := CheckSchema(database, migrations); err != nil {
+		return nil, err
+	}
+	version, err := SchemaVersion(database)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.Version != version {
+			continue
+		}
+
+		err := inTx(database, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return &migration, nil
+	}
+
+	return nil, nil
+}
+
+// MigrationStatuses reports which of migrations are applied to database.
+func MigrationStatuses(database *sql.DB, migrations []Migration) ([]MigrationStatus, error) {
+	if _, err := SchemaVersion(database); err != nil {
+		return nil, err
+	}
+
+	appliedAt := map[int]time.Time{}
+	rows, err := database.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		at, applied := appliedAt[migration.Version]
+		statuses = append(statuses, MigrationStatus{Migration: migration, Applied: applied, AppliedAt: at})
+	}
+
+	return statuses, nil
+}
+
+func inTx(database *sql.DB, run func(tx *sql.Tx) error) error {
+	tx, err := database.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := run(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,155 @@
+package db_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/db"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	database, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Ordered By Version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0010_later.up.sql":   {Data: []byte("up 10")},
+			"m/0010_later.down.sql": {Data: []byte("down 10")},
+			"m/0002_first.up.sql":   {Data: []byte("up 2")},
+			"m/0002_first.down.sql": {Data: []byte("down 2")},
+			"m/README.md":           {Data: []byte("ignored")},
+		}
+
+		migrations, err := db.LoadMigrations(fsys, "m")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []db.Migration{
+				{Version: 2, Name: "first", Up: "up 2", Down: "down 2"},
+				{Version: 10, Name: "later", Up: "up 10", Down: "down 10"},
+			}, migrations)
+		}
+	})
+
+	t.Run("Missing Down", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0001_only.up.sql": {Data: []byte("up")},
+		}
+
+		_, err := db.LoadMigrations(fsys, "m")
+		assert.ErrorContains(t, err, "needs both up and down files")
+	})
+
+	t.Run("Bad Name", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/first.up.sql": {Data: []byte("up")},
+		}
+
+		_, err := db.LoadMigrations(fsys, "m")
+		assert.Error(t, err)
+	})
+
+	t.Run("Embedded Migrations", func(t *testing.T) {
+		assert.NotEmpty(t, db.ReviewMigrations)
+		assert.NotEmpty(t, db.DictionaryMigrations)
+		assert.NotEmpty(t, db.RateLimitMigrations)
+	})
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	database := openTestDB(t)
+
+	applied, err := db.MigrateUp(database, db.ReviewMigrations)
+	if assert.NoError(t, err) {
+		assert.Len(t, applied, len(db.ReviewMigrations))
+	}
+
+	applied, err = db.MigrateUp(database, db.ReviewMigrations)
+	if assert.NoError(t, err) {
+		assert.Empty(t, applied)
+	}
+
+	// Walk all the way down and back up to check every down file.
+	for i := len(db.ReviewMigrations) - 1; i >= 0; i-- {
+		reverted, err := db.MigrateDown(database, db.ReviewMigrations)
+		if assert.NoError(t, err) && assert.NotNil(t, reverted) {
+			assert.Equal(t, db.ReviewMigrations[i].Version, reverted.Version)
+		}
+	}
+	reverted, err := db.MigrateDown(database, db.ReviewMigrations)
+	assert.NoError(t, err)
+	assert.Nil(t, reverted)
+
+	_, err = db.MigrateUp(database, db.ReviewMigrations)
+	assert.NoError(t, err)
+}
+
+func TestMigrateExistingDatabase(t *testing.T) {
+	database := openTestDB(t)
+
+	// The schema every review.db had before migrations existed.
+	_, err := database.Exec(`
+		CREATE TABLE IF NOT EXISTS
+		review (
+			review_id INTEGER PRIMARY KEY,
+			review TEXT
+		);
+		INSERT INTO review (review) VALUES ('Crispy pork belly');
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.MigrateUp(database, db.ReviewMigrations)
+	if assert.NoError(t, err) {
+		var content, status string
+		err := database.QueryRow("SELECT review, status FROM review WHERE review_id = 1").Scan(&content, &status)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Crispy pork belly", content)
+			assert.Equal(t, "approved", status)
+		}
+	}
+}
+
+func TestMigrationStatuses(t *testing.T) {
+	database := openTestDB(t)
+	migrations := db.ReviewMigrations[:2]
+
+	_, err := db.MigrateUp(database, migrations[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := db.MigrationStatuses(database, migrations)
+	if assert.NoError(t, err) && assert.Len(t, statuses, 2) {
+		assert.True(t, statuses[0].Applied)
+		assert.False(t, statuses[0].AppliedAt.IsZero())
+		assert.False(t, statuses[1].Applied)
+	}
+
+	_, err = db.MigrateUp(database, migrations)
+	assert.NoError(t, err)
+	err = db.CheckSchema(database, migrations[:1])
+	assert.ErrorIs(t, err, db.ErrSchemaTooNew)
+}
+
+func TestCheckSchemaCurrent(t *testing.T) {
+	database := openTestDB(t)
+
+	_, err := db.MigrateUp(database, db.ReviewMigrations[:2])
+	if assert.NoError(t, err) {
+		assert.ErrorIs(t, db.CheckSchemaCurrent(database, db.ReviewMigrations), db.ErrSchemaBehind)
+		assert.ErrorIs(t, db.CheckSchemaCurrent(database, db.ReviewMigrations[:1]), db.ErrSchemaTooNew)
+		assert.NoError(t, db.CheckSchemaCurrent(database, db.ReviewMigrations[:2]))
+	}
+	assert.Equal(t, db.ReviewMigrations[1].Version, db.LatestVersion(db.ReviewMigrations[:2]))
+	assert.Zero(t, db.LatestVersion(nil))
+}
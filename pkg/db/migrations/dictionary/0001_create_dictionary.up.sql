@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS
+dictionary (
+	keyword TEXT
+);
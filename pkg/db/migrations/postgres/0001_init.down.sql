@@ -0,0 +1,5 @@
+DROP TABLE dictionary;
+DROP TABLE keyword_mention;
+DROP TABLE review_audit;
+DROP TABLE review_flag;
+DROP TABLE review;
@@ -0,0 +1,44 @@
+-- Postgres keeps reviews and the dictionary in one database. The schema
+-- matches the SQLite migrations as of 0005_fingerprint.
+CREATE TABLE review (
+	review_id BIGSERIAL PRIMARY KEY,
+	review TEXT,
+	status TEXT NOT NULL DEFAULT 'pending',
+	sentiment TEXT NOT NULL DEFAULT 'neutral',
+	sentiment_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+	content_hash TEXT NOT NULL DEFAULT '',
+	simhash BIGINT NOT NULL DEFAULT 0,
+	-- The simple configuration neither stems nor drops stopwords, so a
+	-- keyword matches the words it is written with.
+	search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', coalesce(review, ''))) STORED
+);
+CREATE INDEX review_content_hash ON review (content_hash);
+CREATE INDEX review_search ON review USING GIN (search);
+
+CREATE TABLE review_flag (
+	flag_id BIGSERIAL PRIMARY KEY,
+	review_id BIGINT NOT NULL REFERENCES review (review_id) ON DELETE CASCADE,
+	reason TEXT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	resolved BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE review_audit (
+	audit_id BIGSERIAL PRIMARY KEY,
+	review_id BIGINT NOT NULL REFERENCES review (review_id) ON DELETE CASCADE,
+	action TEXT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc')
+);
+
+CREATE TABLE keyword_mention (
+	review_id BIGINT NOT NULL REFERENCES review (review_id) ON DELETE CASCADE,
+	keyword TEXT NOT NULL,
+	mentions INTEGER NOT NULL,
+	PRIMARY KEY (review_id, keyword)
+);
+
+CREATE TABLE dictionary (
+	keyword TEXT PRIMARY KEY
+);
@@ -0,0 +1,3 @@
+-- Existing reviews count as last updated when they were created.
+ALTER TABLE review ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc');
+UPDATE review SET updated_at = created_at;
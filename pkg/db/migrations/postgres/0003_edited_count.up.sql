@@ -0,0 +1 @@
+ALTER TABLE review ADD COLUMN edited_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- The Postgres counterpart of the SQLite 0008_backfill, for reviews imported
+-- without the columns only Go can compute.
+CREATE TABLE pending_backfill (
+	name TEXT PRIMARY KEY
+);
+INSERT INTO pending_backfill (name) VALUES ('fingerprint'), ('keyword_mention'), ('sentiment');
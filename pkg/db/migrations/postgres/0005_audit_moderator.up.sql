@@ -0,0 +1,3 @@
+-- Who took each moderator action. Flags raised by readers and the system,
+-- and actions audited before this column, have none.
+ALTER TABLE review_audit ADD COLUMN moderator TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS
+rate_limit_bucket (
+	bucket_key TEXT PRIMARY KEY,
+	tokens REAL NOT NULL,
+	updated_at INTEGER NOT NULL,
+	full_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_bucket_full_at ON rate_limit_bucket (full_at);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS
+review (
+	review_id INTEGER PRIMARY KEY,
+	review TEXT
+);
@@ -0,0 +1,3 @@
+DROP TABLE review_audit;
+DROP TABLE review_flag;
+ALTER TABLE review DROP COLUMN status;
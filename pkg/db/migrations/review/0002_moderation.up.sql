@@ -0,0 +1,19 @@
+-- Reviews written before moderation existed were already public.
+ALTER TABLE review ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+UPDATE review SET status = 'approved';
+
+CREATE TABLE review_flag (
+	flag_id INTEGER PRIMARY KEY,
+	review_id INTEGER NOT NULL REFERENCES review (review_id),
+	reason TEXT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	resolved INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE review_audit (
+	audit_id INTEGER PRIMARY KEY,
+	review_id INTEGER NOT NULL REFERENCES review (review_id),
+	action TEXT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+ALTER TABLE review DROP COLUMN sentiment_score;
+ALTER TABLE review DROP COLUMN sentiment;
@@ -0,0 +1,2 @@
+ALTER TABLE review ADD COLUMN sentiment TEXT NOT NULL DEFAULT 'neutral';
+ALTER TABLE review ADD COLUMN sentiment_score REAL NOT NULL DEFAULT 0;
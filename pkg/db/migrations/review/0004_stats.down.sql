@@ -0,0 +1,2 @@
+DROP TABLE keyword_mention;
+ALTER TABLE review DROP COLUMN created_at;
@@ -0,0 +1,21 @@
+-- SQLite can't add a column defaulting to CURRENT_TIMESTAMP, so the table is
+-- rebuilt. Existing reviews get the time of the migration.
+CREATE TABLE review_new (
+	review_id INTEGER PRIMARY KEY,
+	review TEXT,
+	status TEXT NOT NULL DEFAULT 'pending',
+	sentiment TEXT NOT NULL DEFAULT 'neutral',
+	sentiment_score REAL NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO review_new (review_id, review, status, sentiment, sentiment_score)
+	SELECT review_id, review, status, sentiment, sentiment_score FROM review;
+DROP TABLE review;
+ALTER TABLE review_new RENAME TO review;
+
+CREATE TABLE keyword_mention (
+	review_id INTEGER NOT NULL REFERENCES review (review_id),
+	keyword TEXT NOT NULL,
+	mentions INTEGER NOT NULL,
+	PRIMARY KEY (review_id, keyword)
+);
@@ -0,0 +1,3 @@
+DROP INDEX review_content_hash;
+ALTER TABLE review DROP COLUMN simhash;
+ALTER TABLE review DROP COLUMN content_hash;
@@ -0,0 +1,3 @@
+ALTER TABLE review ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE review ADD COLUMN simhash INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX review_content_hash ON review (content_hash);
@@ -0,0 +1,4 @@
+-- A constant default is all SQLite allows when adding a column; existing
+-- reviews count as last updated when they were created.
+ALTER TABLE review ADD COLUMN updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+UPDATE review SET updated_at = created_at;
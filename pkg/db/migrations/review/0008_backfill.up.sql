@@ -0,0 +1,9 @@
+-- Some columns added by earlier migrations can only be computed in Go, so
+-- the migration queues a backfill by name and the server runs it after
+-- migrating. Legacy reviews were left neutral by 0003_sentiment, have no
+-- keyword_mention rows from 0004_stats and kept an empty content_hash from
+-- 0005_fingerprint.
+CREATE TABLE pending_backfill (
+	name TEXT PRIMARY KEY
+);
+INSERT INTO pending_backfill (name) VALUES ('fingerprint'), ('keyword_mention'), ('sentiment');
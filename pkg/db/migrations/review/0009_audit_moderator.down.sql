@@ -0,0 +1 @@
+ALTER TABLE review_audit DROP COLUMN moderator;
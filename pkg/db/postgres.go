@@ -0,0 +1,75 @@
+package db
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgresMigrations are the schema migrations of the Postgres database,
+// which holds both the reviews and the dictionary.
+var PostgresMigrations = mustLoadMigrations("migrations/postgres")
+
+// NewPostgresDB describes the Postgres database at dataSource without
+// connecting to it.
+func NewPostgresDB(dataSource string) *PostgresDB {
+	return &PostgresDB{
+		Driver:     "postgres",
+		DataSource: dataSource,
+		Migrations: PostgresMigrations,
+	}
+}
+
+type PostgresDB struct {
+	Driver     string
+	DataSource string
+	Migrations []Migration
+	Database   *sql.DB
+}
+
+// Open connects to the database without touching its schema. Unlike
+// sql.Open it pings the server so a bad DSN fails at startup.
+func (db *PostgresDB) Open() error {
+	database, err := sql.Open(db.Driver, db.DataSource)
+	if err != nil {
+		return err
+	}
+
+	err = database.Ping()
+	if err != nil {
+		database.Close()
+		return err
+	}
+
+	db.Database = database
+	return nil
+}
+
+// Init opens the database and applies any pending migrations. It refuses a
+// schema newer than the known migrations.
+func (db *PostgresDB) Init() error {
+	err := db.Open()
+	if err != nil {
+		return err
+	}
+
+	_, err = MigrateUp(db.Database, db.Migrations)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *PostgresDB) GetDB() *sql.DB {
+	return db.Database
+}
+
+// Close releases the connection pool. It does nothing if the database was
+// never opened.
+func (db *PostgresDB) Close() error {
+	if db.Database == nil {
+		return nil
+	}
+	return db.Database.Close()
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// RateLimitMigrations are the rate limit database's schema migrations
+// embedded in the binary.
+var RateLimitMigrations = mustLoadMigrations("migrations/ratelimit")
+
+// NewRateLimitDB describes the rate limit database in the SQLite file at
+// dataSource without opening it.
+func NewRateLimitDB(dataSource string) *RateLimitDB {
+	return &RateLimitDB{
+		Driver:     "sqlite3",
+		DataSource: dataSource,
+		Migrations: RateLimitMigrations,
+	}
+}
+
+type RateLimitDB struct {
+	Driver     string
+	DataSource string
+	Migrations []Migration
+	Database   *sql.DB
+}
+
+// Open connects to the database without touching its schema.
+func (db *RateLimitDB) Open() error {
+	database, err := sql.Open(db.Driver, db.DataSource)
+	if err != nil {
+		return err
+	}
+
+	db.Database = database
+	return nil
+}
+
+// Init opens the database and applies any pending migrations. It refuses a
+// schema newer than the known migrations.
+func (db *RateLimitDB) Init() error {
+	err := db.Open()
+	if err != nil {
+		return err
+	}
+
+	_, err = MigrateUp(db.Database, db.Migrations)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *RateLimitDB) GetDB() *sql.DB {
+	return db.Database
+}
+
+// Close releases the connection pool. It does nothing if the database was
+// never opened.
+func (db *RateLimitDB) Close() error {
+	if db.Database == nil {
+		return nil
+	}
+	return db.Database.Close()
+}
@@ -2,54 +2,51 @@ package db
 
 import (
 	"database/sql"
-	"log"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func InitReviewDB() *ReviewDB {
-	driver := "sqlite3"
-	dataSource := "./db/review.db"
-	initStatement := `
-		CREATE TABLE IF NOT EXISTS
-		review (
-			review_id INTEGER PRIMARY KEY,
-			review TEXT
-		)
-	`
-	db := &ReviewDB{
-		Driver:        driver,
-		DataSource:    dataSource,
-		InitStatement: initStatement,
-	}
+// ReviewMigrations are the review database's schema migrations embedded in
+// the binary.
+var ReviewMigrations = mustLoadMigrations("migrations/review")
 
-	err := db.Init()
-	if err != nil {
-		log.Fatal(err)
+// NewReviewDB describes the review database in the SQLite file at dataSource
+// without opening it.
+func NewReviewDB(dataSource string) *ReviewDB {
+	return &ReviewDB{
+		Driver:     "sqlite3",
+		DataSource: dataSource,
+		Migrations: ReviewMigrations,
 	}
-
-	return db
-}
-
-type ReviewDBOpener interface {
-	GetDB() *sql.DB
 }
 
 type ReviewDB struct {
-	Driver        string
-	DataSource    string
-	InitStatement string
-	Database      *sql.DB
+	Driver     string
+	DataSource string
+	Migrations []Migration
+	Database   *sql.DB
 }
 
-func (db *ReviewDB) Init() error {
+// Open connects to the database without touching its schema.
+func (db *ReviewDB) Open() error {
 	database, err := sql.Open(db.Driver, db.DataSource)
 	if err != nil {
 		return err
 	}
 
 	db.Database = database
-	_, err = db.Database.Exec(db.InitStatement)
+	return nil
+}
+
+// Init opens the database and applies any pending migrations. It refuses a
+// schema newer than the known migrations.
+func (db *ReviewDB) Init() error {
+	err := db.Open()
+	if err != nil {
+		return err
+	}
+
+	_, err = MigrateUp(db.Database, db.Migrations)
 	if err != nil {
 		return err
 	}
@@ -60,3 +57,12 @@ func (db *ReviewDB) Init() error {
 func (db *ReviewDB) GetDB() *sql.DB {
 	return db.Database
 }
+
+// Close releases the connection pool. It does nothing if the database was
+// never opened.
+func (db *ReviewDB) Close() error {
+	if db.Database == nil {
+		return nil
+	}
+	return db.Database.Close()
+}
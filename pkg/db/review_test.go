@@ -22,16 +22,21 @@ func TestInit(t *testing.T) {
 		}
 	})
 
-	t.Run("Invalid SQL Init Statement", func(t *testing.T) {
+	t.Run("Invalid SQL Migration", func(t *testing.T) {
 		review := &db.ReviewDB{
 			Driver: "sqlite3",
-			InitStatement: `
-				CREATE TABLE IF NOT EXIT
-				review (
-					review_id INTEGER PRIMARY KEY,
-					review TEXT
-				)
-			`,
+			Migrations: []db.Migration{{
+				Version: 1,
+				Name:    "broken",
+				Up: `
+					CREATE TABLE IF NOT EXIT
+					review (
+						review_id INTEGER PRIMARY KEY,
+						review TEXT
+					)
+				`,
+				Down: "DROP TABLE review",
+			}},
 		}
 
 		expectedErrorSubstring := "syntax error"
@@ -45,20 +50,40 @@ func TestInit(t *testing.T) {
 		review := &db.ReviewDB{
 			Driver:     "sqlite3",
 			DataSource: "./reviewtest.db",
-			InitStatement: `
-				CREATE TABLE IF NOT EXISTS
-				review (
-					review_id INTEGER PRIMARY KEY,
-					review TEXT
-				)
-			`,
+			Migrations: db.ReviewMigrations,
 		}
 
 		err := review.Init()
 		if assert.NoError(t, err) {
+			version, err := db.SchemaVersion(review.GetDB())
+			assert.NoError(t, err)
+			assert.Equal(t, db.ReviewMigrations[len(db.ReviewMigrations)-1].Version, version)
+
 			if err := os.Remove("reviewtest.db"); err != nil {
 				t.Error(err)
 			}
 		}
 	})
+
+	t.Run("Schema Too New", func(t *testing.T) {
+		review := &db.ReviewDB{
+			Driver:     "sqlite3",
+			DataSource: "./reviewtest.db",
+			Migrations: db.ReviewMigrations,
+		}
+		if err := review.Init(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := review.GetDB().Exec("INSERT INTO schema_migrations (version, name) VALUES (999, 'future')")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = review.Init()
+		assert.ErrorIs(t, err, db.ErrSchemaTooNew)
+
+		if err := os.Remove("reviewtest.db"); err != nil {
+			t.Error(err)
+		}
+	})
 }
@@ -0,0 +1,177 @@
+package extract
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed stopwords.txt
+var defaultStopwords string
+
+const (
+	defaultMaxN       = 3
+	defaultMinDocFreq = 2
+)
+
+type Candidate struct {
+	Term    string
+	Score   float64
+	DocFreq int
+}
+
+type Extractor struct {
+	Stopwords map[string]bool
+	// MaxN is the longest n-gram considered, in words.
+	MaxN int
+	// MinDocFreq drops terms seen in fewer reviews than this.
+	MinDocFreq int
+}
+
+func NewExtractor() *Extractor {
+	return &Extractor{
+		Stopwords:  ParseStopwords(defaultStopwords),
+		MaxN:       defaultMaxN,
+		MinDocFreq: defaultMinDocFreq,
+	}
+}
+
+func ParseStopwords(list string) map[string]bool {
+	stopwords := map[string]bool{}
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		stopwords[word] = true
+	}
+
+	return stopwords
+}
+
+// Extract ranks the n-grams of docs by TF-IDF, summed over the documents a
+// term appears in, with longer n-grams weighted up since dish names tend to be
+// phrases. Terms in existing are skipped. At most limit candidates are
+// returned, or all of them when limit is not positive.
+func (e *Extractor) Extract(docs []string, existing []string, limit int) []Candidate {
+	known := map[string]bool{}
+	for _, term := range existing {
+		known[strings.ToLower(term)] = true
+	}
+
+	termFreqs := make([]map[string]int, 0, len(docs))
+	docFreq := map[string]int{}
+	for _, doc := range docs {
+		tf := e.termFrequencies(doc)
+		for term := range tf {
+			docFreq[term]++
+		}
+		termFreqs = append(termFreqs, tf)
+	}
+
+	scores := map[string]float64{}
+	for _, tf := range termFreqs {
+		for term, count := range tf {
+			if known[term] || docFreq[term] < e.MinDocFreq {
+				continue
+			}
+			idf := math.Log(1 + float64(len(docs))/float64(docFreq[term]))
+			scores[term] += float64(count) * idf
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(scores))
+	for term, score := range scores {
+		n := float64(len(strings.Fields(term)))
+		candidates = append(candidates, Candidate{
+			Term:    term,
+			Score:   score * n,
+			DocFreq: docFreq[term],
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates
+}
+
+// termFrequencies counts every 1..MaxN-gram of doc that neither starts nor
+// ends with a stopword. N-grams never cross punctuation.
+func (e *Extractor) termFrequencies(doc string) map[string]int {
+	tf := map[string]int{}
+
+	for _, phrase := range phrases(doc) {
+		for i := range phrase {
+			if e.Stopwords[phrase[i]] || isNumber(phrase[i]) {
+				continue
+			}
+			for n := 1; n <= e.MaxN && i+n <= len(phrase); n++ {
+				last := phrase[i+n-1]
+				if e.Stopwords[last] || isNumber(last) {
+					continue
+				}
+				tf[strings.Join(phrase[i:i+n], " ")]++
+			}
+		}
+	}
+
+	return tf
+}
+
+// phrases lowercases doc and splits it into runs of words between punctuation.
+func phrases(doc string) [][]string {
+	var result [][]string
+	var phrase []string
+	var word []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			phrase = append(phrase, string(word))
+			word = word[:0]
+		}
+	}
+	flushPhrase := func() {
+		flushWord()
+		if len(phrase) > 0 {
+			result = append(result, phrase)
+			phrase = nil
+		}
+	}
+
+	for _, r := range strings.ToLower(doc) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '\'':
+			word = append(word, r)
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			flushPhrase()
+		}
+	}
+	flushPhrase()
+
+	return result
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
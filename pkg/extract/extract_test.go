@@ -0,0 +1,68 @@
+package extract_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/extract"
+)
+
+func terms(candidates []extract.Candidate) []string {
+	var result []string
+	for _, c := range candidates {
+		result = append(result, c.Term)
+	}
+	return result
+}
+
+func TestExtract(t *testing.T) {
+	docs := []string{
+		"The pad thai here is the best, and the mango sticky rice too.",
+		"Came for the pad thai. Stayed for the mango sticky rice!",
+		"Pad thai was cold. Service was slow.",
+		"Ordered 2 bowls of boat noodles",
+	}
+
+	t.Run("Ranks Repeated Phrases", func(t *testing.T) {
+		extractor := extract.NewExtractor()
+
+		candidates := extractor.Extract(docs, nil, 3)
+		if assert.Len(t, candidates, 3) {
+			assert.Equal(t, "mango sticky rice", candidates[0].Term)
+			assert.Equal(t, "pad thai", candidates[1].Term)
+			assert.Equal(t, 3, candidates[1].DocFreq)
+		}
+	})
+
+	t.Run("Skips Stopwords, Numbers and Rare Terms", func(t *testing.T) {
+		extractor := extract.NewExtractor()
+
+		found := terms(extractor.Extract(docs, nil, 0))
+		assert.NotContains(t, found, "the pad thai")
+		assert.NotContains(t, found, "2 bowls")
+		assert.NotContains(t, found, "boat noodles")
+		assert.NotContains(t, found, "thai was cold")
+	})
+
+	t.Run("Skips Existing Dictionary Entries", func(t *testing.T) {
+		extractor := extract.NewExtractor()
+
+		found := terms(extractor.Extract(docs, []string{"Pad Thai"}, 0))
+		assert.NotContains(t, found, "pad thai")
+		assert.Contains(t, found, "mango sticky rice")
+	})
+
+	t.Run("Thai Text", func(t *testing.T) {
+		extractor := extract.NewExtractor()
+		extractor.MaxN = 1
+
+		found := terms(extractor.Extract([]string{"ส้มตำ ไก่ย่าง", "ส้มตำ อร่อย"}, nil, 0))
+		assert.Equal(t, []string{"ส้มตำ"}, found)
+	})
+}
+
+func TestParseStopwords(t *testing.T) {
+	stopwords := extract.ParseStopwords("# comment\nThe\n\n ที่ \n")
+	assert.Equal(t, map[string]bool{"the": true, "ที่": true}, stopwords)
+}
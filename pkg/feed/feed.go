@@ -0,0 +1,216 @@
+// Package feed writes Atom 1.0 and RSS 2.0 documents, so feed readers can
+// follow new reviews.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+)
+
+// Feed is a list of entries, newest first. URLs are absolute.
+type Feed struct {
+	// ID identifies the feed for good; the URL of the feed itself will do.
+	ID    string
+	Title string
+	// Link is the page the feed mirrors, and Self the feed's own URL.
+	Link string
+	Self string
+	// Base is the URL relative links in entry content are resolved against.
+	Base   string
+	Author string
+	// Updated defaults to the latest update of any entry.
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is one item of a feed. Updated defaults to Published.
+type Entry struct {
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+	Updated   time.Time
+	// Content is HTML.
+	Content string
+}
+
+// updated is when anything in the feed last changed. An empty feed with no
+// Updated set reports the Unix epoch rather than the zero time, which some
+// readers refuse.
+func (f *Feed) updated() time.Time {
+	updated := f.Updated
+	for _, entry := range f.Entries {
+		if entry.updated().After(updated) {
+			updated = entry.updated()
+		}
+	}
+	if updated.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+	return updated.UTC()
+}
+
+func (e *Entry) updated() time.Time {
+	if e.Updated.IsZero() {
+		return e.Published
+	}
+	return e.Updated
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Base    string      `xml:"xml:base,attr,omitempty"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published,omitempty"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func atomTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// WriteAtom writes f as an Atom 1.0 document.
+func (f *Feed) WriteAtom(w io.Writer) error {
+	doc := atomFeed{
+		Base:    f.Base,
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: atomTime(f.updated()),
+		Links: []atomLink{
+			{Rel: "alternate", Type: "text/html", Href: f.Link},
+			{Rel: "self", Type: "application/atom+xml", Href: f.Self},
+		},
+	}
+	if f.Author != "" {
+		doc.Author = &atomAuthor{Name: f.Author}
+	}
+	for _, entry := range f.Entries {
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:        entry.ID,
+			Title:     entry.Title,
+			Link:      atomLink{Rel: "alternate", Type: "text/html", Href: entry.Link},
+			Published: atomTime(entry.Published),
+			Updated:   atomTime(entry.updated()),
+			Content:   atomContent{Type: "html", Body: entry.Content},
+		})
+	}
+	return encode(w, doc)
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          rssSelf   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssSelf is the atom:link RSS validators expect to point back at the feed.
+type rssSelf struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// WriteRSS writes f as an RSS 2.0 document. RSS has no per-item update
+// time, so items carry their publication time only.
+func (f *Feed) WriteRSS(w io.Writer) error {
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			Self:          rssSelf{Rel: "self", Type: "application/rss+xml", Href: f.Self},
+			LastBuildDate: rssTime(f.updated()),
+		},
+	}
+	for _, entry := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			GUID:        rssGUID{IsPermaLink: entry.ID == entry.Link, Value: entry.ID},
+			PubDate:     rssTime(entry.Published),
+			Description: entry.Content,
+		})
+	}
+	return encode(w, doc)
+}
+
+func encode(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,120 @@
+package feed_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/feed"
+)
+
+func testFeed() *feed.Feed {
+	return &feed.Feed{
+		ID:     "https://food.example/feeds/reviews.atom",
+		Title:  "Food Review: new reviews",
+		Link:   "https://food.example/reviews",
+		Self:   "https://food.example/feeds/reviews.atom",
+		Base:   "https://food.example/",
+		Author: "Food Review",
+		Entries: []feed.Entry{
+			{
+				ID:        "https://food.example/reviews/2",
+				Title:     "Crispy pork & rice",
+				Link:      "https://food.example/reviews/2",
+				Published: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+				Updated:   time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC),
+				Content:   "<p>Crispy <em>pork</em></p>",
+			},
+			{
+				ID:        "https://food.example/reviews/1",
+				Title:     "Khao soi",
+				Link:      "https://food.example/reviews/1",
+				Published: time.Date(2024, 2, 28, 9, 0, 0, 0, time.FixedZone("ICT", 7*60*60)),
+				Content:   "<p>Rich</p>",
+			},
+		},
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testFeed().WriteAtom(&out))
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Base    string   `xml:"base,attr"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Author  string   `xml:"author>name"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Content   struct {
+				Type string `xml:"type,attr"`
+				Body string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &doc), out.String())
+
+	assert.Equal(t, "https://food.example/", doc.Base)
+	assert.Equal(t, "2024-03-02T08:30:00Z", doc.Updated, "the feed is as new as its newest entry")
+	assert.Equal(t, "Food Review", doc.Author)
+	if assert.Len(t, doc.Links, 2) {
+		assert.Equal(t, "self", doc.Links[1].Rel)
+		assert.Equal(t, "https://food.example/feeds/reviews.atom", doc.Links[1].Href)
+	}
+	if assert.Len(t, doc.Entries, 2) {
+		assert.Equal(t, "Crispy pork & rice", doc.Entries[0].Title)
+		assert.Equal(t, "html", doc.Entries[0].Content.Type)
+		assert.Equal(t, "<p>Crispy <em>pork</em></p>", doc.Entries[0].Content.Body)
+		assert.Equal(t, "2024-02-28T02:00:00Z", doc.Entries[1].Published)
+		assert.Equal(t, "2024-02-28T02:00:00Z", doc.Entries[1].Updated, "updated defaults to published")
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, testFeed().WriteRSS(&out))
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				GUID        string `xml:"guid"`
+				PubDate     string `xml:"pubDate"`
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &doc), out.String())
+
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Contains(t, out.String(), "<link>https://food.example/reviews</link>")
+	assert.Contains(t, out.String(), `<atom:link rel="self" type="application/rss+xml" href="https://food.example/feeds/reviews.atom">`)
+	assert.Equal(t, "Sat, 02 Mar 2024 08:30:00 +0000", doc.Channel.LastBuildDate)
+	if assert.Len(t, doc.Channel.Items, 2) {
+		assert.Equal(t, "https://food.example/reviews/2", doc.Channel.Items[0].GUID)
+		assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 +0000", doc.Channel.Items[0].PubDate)
+		assert.Equal(t, "<p>Crispy <em>pork</em></p>", doc.Channel.Items[0].Description)
+	}
+}
+
+func TestEmptyFeed(t *testing.T) {
+	var out bytes.Buffer
+	empty := &feed.Feed{ID: "https://food.example/feeds/search.atom?query=larb", Title: "larb"}
+	require.NoError(t, empty.WriteAtom(&out))
+	assert.Contains(t, out.String(), "<updated>1970-01-01T00:00:00Z</updated>")
+	assert.NotContains(t, out.String(), "<entry>")
+}
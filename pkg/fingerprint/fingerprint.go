@@ -0,0 +1,96 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// NearDuplicateDistance is the largest SimHash Hamming distance at which two
+// texts are treated as near-duplicates.
+const NearDuplicateDistance = 3
+
+// shingleSize is the number of consecutive words hashed together as one
+// SimHash feature.
+const shingleSize = 2
+
+// Normalize lowercases text and reduces everything that is not a letter or a
+// digit to single spaces, so whitespace and punctuation edits don't matter.
+func Normalize(text string) string {
+	var words []string
+	var word []rune
+
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			word = append(word, r)
+			continue
+		}
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// ContentHash identifies exact duplicates after normalisation.
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(Normalize(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHash hashes word shingles of text into a 64-bit fingerprint where
+// similar texts differ in only a few bits.
+func SimHash(text string) uint64 {
+	words := strings.Fields(Normalize(text))
+	if len(words) == 0 {
+		return 0
+	}
+
+	var features []string
+	if len(words) < shingleSize {
+		features = words
+	} else {
+		for i := 0; i+shingleSize <= len(words); i++ {
+			features = append(features, strings.Join(words[i:i+shingleSize], " "))
+		}
+	}
+
+	var weights [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+
+	return fingerprint
+}
+
+func Distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func NearDuplicate(a uint64, b uint64) bool {
+	return Distance(a, b) <= NearDuplicateDistance
+}
@@ -0,0 +1,55 @@
+package fingerprint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/fingerprint"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "great pad thai 10 10", fingerprint.Normalize("  Great   PAD-THAI!! 10/10 "))
+	assert.Equal(t, "ต้มยำ อร่อย", fingerprint.Normalize("ต้มยำ, อร่อย"))
+}
+
+func TestContentHash(t *testing.T) {
+	t.Run("Ignores Case and Punctuation", func(t *testing.T) {
+		a := fingerprint.ContentHash("Best som tam in town!")
+		b := fingerprint.ContentHash("best  som tam in town")
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("Different Words", func(t *testing.T) {
+		a := fingerprint.ContentHash("Best som tam in town")
+		b := fingerprint.ContentHash("Best som tam in the city")
+		assert.NotEqual(t, a, b)
+	})
+}
+
+func TestSimHash(t *testing.T) {
+	original := "We came here for dinner on Friday. The green curry was rich and creamy, " +
+		"the roti was crispy and the mango sticky rice was the best we have had in Bangkok. " +
+		"Service was quick and friendly. Will definitely come back with friends."
+
+	t.Run("Tiny Edit Is Near Duplicate", func(t *testing.T) {
+		edited := original + " Highly recommended!"
+		assert.True(t, fingerprint.NearDuplicate(fingerprint.SimHash(original), fingerprint.SimHash(edited)),
+			"distance %d", fingerprint.Distance(fingerprint.SimHash(original), fingerprint.SimHash(edited)))
+	})
+
+	t.Run("Unrelated Review Is Not", func(t *testing.T) {
+		other := "Terrible experience. The noodles were cold, the soup was bland and " +
+			"we waited forty minutes for a table even though the place was half empty."
+		assert.False(t, fingerprint.NearDuplicate(fingerprint.SimHash(original), fingerprint.SimHash(other)))
+	})
+
+	t.Run("Empty Text", func(t *testing.T) {
+		assert.Equal(t, uint64(0), fingerprint.SimHash(" !! "))
+	})
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, fingerprint.Distance(0xff, 0xff))
+	assert.Equal(t, 2, fingerprint.Distance(0b1010, 0b0110))
+}
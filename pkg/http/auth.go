@@ -0,0 +1,97 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"mime"
+	"net/http"
+
+	"food-review/pkg/i18n"
+	"food-review/pkg/route"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// moderators holds the SHA-256 of each moderator's key by name. Comparing
+// hashes keeps the comparison constant-time whatever the key lengths.
+type moderators map[string][sha256.Size]byte
+
+func newModerators(keys map[string]string) moderators {
+	m := moderators{}
+	for name, key := range keys {
+		m[name] = sha256.Sum256([]byte(key))
+	}
+	return m
+}
+
+// authenticate returns the moderator whose key the request carries, in the
+// X-API-Key header or as the basic auth password for their name, or "" if
+// it carries none that is valid.
+func (m moderators) authenticate(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		found := ""
+		for name, want := range m {
+			if subtle.ConstantTimeCompare(sum[:], want[:]) == 1 {
+				found = name
+			}
+		}
+		return found
+	}
+	if name, key, ok := r.BasicAuth(); ok {
+		want, known := m[name]
+		sum := sha256.Sum256([]byte(key))
+		if known && subtle.ConstantTimeCompare(sum[:], want[:]) == 1 {
+			return name
+		}
+	}
+	return ""
+}
+
+// authenticated is mux middleware recording in the request context, and as
+// the principal of the request log, which moderator, if any, made the
+// request. It turns nobody away; moderatorOnly does that for the routes that
+// need it.
+func authenticated(m moderators) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if name := m.authenticate(r); name != "" {
+				r = r.WithContext(route.WithModerator(r.Context(), name))
+				if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+					info.principal = name
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// moderatorOnly answers 401 to requests no moderator made, asking browsers
+// to sign in with basic auth.
+func moderatorOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if route.ModeratorFromContext(r.Context()) == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="food-review moderation", charset="UTF-8"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(i18n.FromContext(r.Context()).T("Moderator sign-in required")))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// jsonOnly answers 415 to requests whose body isn't declared as JSON. A
+// browser won't send that content type to another site without a CORS
+// preflight, which this server never answers, so other sites can't ride on
+// a moderator's saved basic auth to post forms here.
+func jsonOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			w.Write([]byte(i18n.FromContext(r.Context()).T("Requests must be sent as JSON")))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"food-review/pkg/cache"
+	"food-review/pkg/metrics"
+	"food-review/pkg/tracing"
+)
+
+const (
+	maxCachedPages = 512
+	// pageTTL bounds how stale a cached page gets for changes that aren't
+	// writes, such as the stats window moving on to a new day.
+	pageTTL = 5 * time.Minute
+)
+
+// readOperations are the store calls that don't change data. Every other
+// call invalidates the page cache, so a new store method is safe by default.
+var readOperations = map[string]bool{
+	"review.List":              true,
+	"review.Get":               true,
+	"review.Search":            true,
+	"review.ModerationQueue":   true,
+	"review.KeywordSentiments": true,
+	"review.KeywordAspects":    true,
+	"review.KeywordCandidates": true,
+	"review.Stats":             true,
+	"review.DuplicateClusters": true,
+	"dictionary.List":          true,
+	"dictionary.KeywordExists": true,
+}
+
+// pageCache caches the public pages, which only change when reviews or the
+// dictionary are written, and answers conditional GETs for them.
+type pageCache struct {
+	cache    *cache.Cache
+	requests *metrics.CounterVec
+}
+
+func newPageCache(serverMetrics *serverMetrics) *pageCache {
+	return &pageCache{
+		cache:    cache.New(maxCachedPages, pageTTL),
+		requests: serverMetrics.cacheRequests,
+	}
+}
+
+// invalidateOnWrite is a store.Observer emptying the cache once a write
+// finishes, whether or not it succeeded.
+func (p *pageCache) invalidateOnWrite(store string, operation string) func(err error) {
+	if readOperations[store+"."+operation] {
+		return func(err error) {}
+	}
+	return func(err error) {
+		p.cache.Invalidate()
+	}
+}
+
+// cached serves next's page from the cache when it can. Pages are keyed by
+// language, site, path and query, the site because feeds link back to it
+// absolutely, and carry an ETag and Last-Modified, so clients and CDNs can
+// revalidate with a conditional GET. Only 200 responses are cached. A nil
+// cache serves next as is.
+func (p *pageCache) cached(next http.HandlerFunc) http.HandlerFunc {
+	if p == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		site := r.Header.Get("X-Forwarded-Proto") + "://" + r.Host
+		key := w.Header().Get("Content-Language") + " " + site + r.URL.Path + "?" + r.URL.Query().Encode()
+		span := tracing.SpanFromContext(r.Context())
+
+		if entry, ok := p.cache.Get(key); ok {
+			p.requests.Inc(routeTemplate(r), "hit")
+			span.SetAttributes("cache", "hit")
+			serveEntry(w, r, entry)
+			return
+		}
+		p.requests.Inc(routeTemplate(r), "miss")
+		span.SetAttributes("cache", "miss")
+
+		// Both are taken before the handler reads anything, so a write
+		// landing meanwhile keeps the page out of the cache.
+		generation := p.cache.Generation()
+		modified := p.cache.Modified()
+
+		buffer := &bufferedResponse{ResponseWriter: w}
+		next(buffer, r)
+
+		if buffer.status != 0 && buffer.status != http.StatusOK {
+			w.WriteHeader(buffer.status)
+			w.Write(buffer.body.Bytes())
+			return
+		}
+
+		body := buffer.body.Bytes()
+		sum := sha256.Sum256(body)
+		entry := &cache.Entry{
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        body,
+			ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+			Modified:    modified,
+		}
+		p.cache.Put(key, entry, generation)
+		serveEntry(w, r, entry)
+	}
+}
+
+// serveEntry writes a cached page, or 304 Not Modified when the request's
+// If-None-Match or If-Modified-Since shows the client already has it.
+func serveEntry(w http.ResponseWriter, r *http.Request, entry *cache.Entry) {
+	header := w.Header()
+	header.Set("Content-Type", entry.ContentType)
+	header.Set("ETag", entry.ETag)
+	// Caches may keep the page but must revalidate before reusing it.
+	header.Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, "", entry.Modified, bytes.NewReader(entry.Body))
+}
+
+// bufferedResponse holds back the status and body a handler writes so the
+// page can be cached before it is sent. Headers go straight to the
+// underlying response.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
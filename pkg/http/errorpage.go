@@ -0,0 +1,120 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"food-review/pkg/i18n"
+	"food-review/pkg/logging"
+	"food-review/pkg/template"
+)
+
+// maxErrorMessage caps how much of a handler's error message is kept for the
+// error page.
+const maxErrorMessage = 1 << 10
+
+// errorPageStatuses are the errors shown to browsers as a page of the site.
+var errorPageStatuses = map[int]bool{
+	http.StatusNotFound:            true,
+	http.StatusUnprocessableEntity: true,
+	http.StatusInternalServerError: true,
+}
+
+// ErrorPage is what error.html renders.
+type ErrorPage struct {
+	Status     int
+	StatusText string
+	Message    string
+	RequestID  string
+}
+
+// withErrorPages renders 404, 422 and 500 responses with error.html for
+// browsers, which ask for text/html. Everyone else, such as the page scripts
+// calling the API with fetch, keeps the handler's plain text message.
+func withErrorPages(templater template.Templater, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &errorPageWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		if ew.status == 0 {
+			return
+		}
+
+		printer := i18n.FromContext(r.Context())
+		page := ErrorPage{
+			Status:     ew.status,
+			StatusText: http.StatusText(ew.status),
+			// Handlers translate their own messages; this catches the
+			// router's 404.
+			Message: printer.T(strings.TrimSpace(ew.message.String())),
+		}
+		switch {
+		case ew.status == http.StatusInternalServerError:
+			// The error may say more about the server than a visitor should
+			// see; the request ID finds it in the logs.
+			page.Message = printer.T("Something went wrong on our side. Please try again later.")
+			page.RequestID = w.Header().Get(requestIDHeader)
+		case page.Message == "":
+			page.Message = printer.T(page.StatusText)
+		}
+
+		header := w.Header()
+		header.Del("Content-Length")
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		header.Set("X-Content-Type-Options", "nosniff")
+
+		// The page is rendered into a buffer so a broken template can still
+		// fall back to plain text.
+		buffered := &bufferedResponse{ResponseWriter: w}
+		if err := templater.ExecuteTemplate(buffered, "error.html", page); err != nil {
+			logging.FromContext(r.Context()).Error("error page", "error", err)
+			header.Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(ew.status)
+			w.Write([]byte(page.Message))
+			return
+		}
+		w.WriteHeader(ew.status)
+		w.Write(buffered.body.Bytes())
+	})
+}
+
+// errorPageWriter passes a response through unless its status gets an error
+// page, in which case it holds on to the message instead of writing it.
+type errorPageWriter struct {
+	http.ResponseWriter
+	// status is set once an error page is due.
+	status  int
+	wrote   bool
+	message strings.Builder
+}
+
+func (w *errorPageWriter) WriteHeader(status int) {
+	if w.wrote || w.status != 0 {
+		return
+	}
+	if errorPageStatuses[status] {
+		w.status = status
+		return
+	}
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *errorPageWriter) Write(p []byte) (int, error) {
+	if w.status != 0 {
+		if room := maxErrorMessage - w.message.Len(); room > 0 {
+			if len(p) > room {
+				w.message.Write(p[:room])
+			} else {
+				w.message.Write(p)
+			}
+		}
+		return len(p), nil
+	}
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
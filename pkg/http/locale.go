@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"food-review/pkg/i18n"
+	"food-review/pkg/web"
+)
+
+// localeCookieMaxAge keeps a reader's chosen language for a year.
+const localeCookieMaxAge = 365 * 24 * 60 * 60
+
+// localized negotiates the request's locale and puts its printer in the
+// request context. The locale is announced in Content-Language, which is
+// also what the templates render in and what the page cache keys on. A
+// language picked with ?lang= is remembered in a cookie.
+func localized(catalog *i18n.Catalog, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probePaths[r.URL.Path] || strings.HasPrefix(r.URL.Path, web.StaticPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		locale := catalog.Negotiate(r)
+		if chosen, ok := catalog.Match(r.URL.Query().Get(i18n.LocaleParam)); ok {
+			http.SetCookie(w, &http.Cookie{
+				Name:     i18n.LocaleParam,
+				Value:    chosen,
+				Path:     "/",
+				MaxAge:   localeCookieMaxAge,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		header := w.Header()
+		header.Set("Content-Language", locale)
+		header.Add("Vary", "Accept-Language")
+		header.Add("Vary", "Cookie")
+
+		ctx := i18n.NewContext(r.Context(), catalog.Printer(locale))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
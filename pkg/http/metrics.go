@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"food-review/pkg/metrics"
+	"food-review/pkg/template"
+)
+
+// serverMetrics are the metrics served on /metrics.
+type serverMetrics struct {
+	registry         *metrics.Registry
+	requests         *metrics.CounterVec
+	requestDuration  *metrics.HistogramVec
+	templateDuration *metrics.HistogramVec
+	queryDuration    *metrics.HistogramVec
+	cacheRequests    *metrics.CounterVec
+}
+
+func newServerMetrics() *serverMetrics {
+	registry := metrics.NewRegistry()
+	return &serverMetrics{
+		registry: registry,
+		requests: registry.NewCounterVec("food_review_http_requests_total",
+			"HTTP requests by route template and status code.", "method", "route", "code"),
+		requestDuration: registry.NewHistogramVec("food_review_http_request_duration_seconds",
+			"Time to serve HTTP requests by route template.", metrics.DefaultBuckets, "method", "route"),
+		templateDuration: registry.NewHistogramVec("food_review_template_render_duration_seconds",
+			"Time to execute each HTML template.", metrics.DefaultBuckets, "template"),
+		queryDuration: registry.NewHistogramVec("food_review_db_query_duration_seconds",
+			"Time spent in each store call, including its SQL queries.", metrics.DefaultBuckets, "store", "operation"),
+		cacheRequests: registry.NewCounterVec("food_review_page_cache_requests_total",
+			"Requests for cacheable pages by route template and whether the cache had them.", "route", "result"),
+	}
+}
+
+// statusRecorder remembers the status code and body size a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+// routeTemplate is the path template of the mux route serving r, such as
+// /reviews/{reviewID}, so metrics don't get a series per review.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if path, err := route.GetPathTemplate(); err == nil {
+			return path
+		}
+	}
+	return "unmatched"
+}
+
+// instrument is mux middleware counting and timing each matched route.
+func (m *serverMetrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		route := routeTemplate(r)
+		m.requests.Inc(r.Method, route, strconv.Itoa(recorder.status))
+		m.requestDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// observeStore times store calls, each of which stands for one model
+// function and the SQL queries it runs.
+func (m *serverMetrics) observeStore(store string, operation string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		m.queryDuration.Observe(time.Since(start).Seconds(), store, operation)
+	}
+}
+
+// timedTemplater times every template execution of Next.
+type timedTemplater struct {
+	next     template.Templater
+	duration *metrics.HistogramVec
+}
+
+func (t *timedTemplater) ExecuteTemplate(w http.ResponseWriter, name string, data interface{}) error {
+	start := time.Now()
+	err := t.next.ExecuteTemplate(w, name, data)
+	t.duration.Observe(time.Since(start).Seconds(), name)
+	return err
+}
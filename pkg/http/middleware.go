@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"food-review/pkg/logging"
+	"food-review/pkg/tracing"
+)
+
+const (
+	requestIDHeader   = "X-Request-ID"
+	traceparentHeader = "traceparent"
+)
+
+// maxRequestIDLength caps an incoming request ID so a client can't fill the
+// logs through the header.
+const maxRequestIDLength = 128
+
+// probePaths are polled by the orchestrator and Prometheus every few
+// seconds, so they are left out of the request log and traces.
+var probePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/version": true,
+	"/metrics": true,
+}
+
+// requestInfo is filled in while the request passes through the router, for
+// the log line written once it has been served.
+type requestInfo struct {
+	route     string
+	principal string
+}
+
+type requestInfoKey struct{}
+
+// withRequestLogging gives every request an ID, echoes it in the
+// X-Request-ID response header and puts a logger tagged with it in the
+// request context, so handlers and store calls log lines that can be tied
+// back to the request. Once the request is served it logs one line with the
+// outcome, at WARN for client errors and ERROR for server errors.
+func withRequestLogging(logger *logging.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		reqLogger := logger.With("request_id", id)
+		if span := tracing.SpanFromContext(r.Context()); span != nil {
+			reqLogger = reqLogger.With("trace_id", span.SpanContext().TraceID.String())
+		}
+		info := &requestInfo{route: "unmatched", principal: "anonymous"}
+		ctx := logging.NewContext(r.Context(), reqLogger)
+		ctx = context.WithValue(ctx, requestInfoKey{}, info)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		if probePaths[r.URL.Path] {
+			return
+		}
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		level := logging.LevelInfo
+		switch {
+		case recorder.status >= 500:
+			level = logging.LevelError
+		case recorder.status >= 400:
+			level = logging.LevelWarn
+		}
+		reqLogger.Log(level, "request",
+			"method", r.Method,
+			"route", info.route,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"principal", info.principal,
+		)
+	})
+}
+
+// withTracing starts a server span for every request, continuing the trace
+// in an incoming traceparent header. The span is named after the method and,
+// once the router has matched it, the route template.
+func withTracing(tracer *tracing.Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probePaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		remote, _ := tracing.ParseTraceparent(r.Header.Get(traceparentHeader))
+		ctx, span := tracer.StartServer(r.Context(), r.Method, remote)
+		defer span.End()
+		span.SetAttributes("http.method", r.Method, "http.target", r.URL.Path)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		span.SetAttributes("http.status_code", recorder.status)
+		if recorder.status >= 500 {
+			span.RecordError(fmt.Errorf("%d %s", recorder.status, http.StatusText(recorder.status)))
+		}
+	})
+}
+
+// recordRoute is mux middleware noting the matched route template for the
+// request log and span, which start outside the router and can't see it.
+func recordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+			info.route = route
+		}
+		if span := tracing.SpanFromContext(r.Context()); span != nil {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes("http.route", route)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,530 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	server "food-review/pkg/http"
+	"food-review/pkg/logging"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var out bytes.Buffer
+	previous := logging.Default()
+	logging.SetDefault(logging.New(&out, logging.LevelDebug))
+	defer logging.SetDefault(previous)
+
+	srv, err := server.NewServer(testConfig(t))
+	require.NoError(t, err)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		out.Reset()
+		recorder := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+	logLines := func(t *testing.T) []map[string]interface{} {
+		var lines []map[string]interface{}
+		for _, data := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(data), &line), data)
+			lines = append(lines, line)
+		}
+		return lines
+	}
+	// logLine is the request line, which comes after any logged while serving.
+	logLine := func(t *testing.T) map[string]interface{} {
+		lines := logLines(t)
+		return lines[len(lines)-1]
+	}
+
+	t.Run("Generates Request ID", func(t *testing.T) {
+		resp := serve(httptest.NewRequest("GET", "/reviews/1", nil))
+
+		id := resp.Header().Get("X-Request-ID")
+		assert.Len(t, id, 32)
+
+		line := logLine(t)
+		assert.Equal(t, "WARN", line["level"])
+		assert.Equal(t, "request", line["msg"])
+		assert.Equal(t, id, line["request_id"])
+		assert.Equal(t, "GET", line["method"])
+		assert.Equal(t, "/reviews/{reviewID}", line["route"])
+		assert.Equal(t, "/reviews/1", line["path"])
+		assert.EqualValues(t, resp.Code, line["status"])
+		assert.EqualValues(t, resp.Body.Len(), line["bytes"])
+		assert.Contains(t, line, "latency_ms")
+		assert.Equal(t, "anonymous", line["principal"])
+	})
+
+	t.Run("Propagates Request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/reviews", nil)
+		req.Header.Set("X-Request-ID", "edge-1234")
+		req.SetBasicAuth("moderator", moderatorKey)
+		resp := serve(req)
+
+		assert.Equal(t, "edge-1234", resp.Header().Get("X-Request-ID"))
+		line := logLine(t)
+		assert.Equal(t, "INFO", line["level"])
+		assert.Equal(t, "edge-1234", line["request_id"])
+		assert.Equal(t, "moderator", line["principal"])
+	})
+
+	t.Run("Correlates Store Calls", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/moderation", nil)
+		req.Header.Set("X-API-Key", moderatorKey)
+		resp := serve(req)
+
+		lines := logLines(t)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "DEBUG", lines[0]["level"])
+		assert.Equal(t, "store call", lines[0]["msg"])
+		assert.Equal(t, "review", lines[0]["store"])
+		assert.Equal(t, "ModerationQueue", lines[0]["operation"])
+		assert.Equal(t, resp.Header().Get("X-Request-ID"), lines[0]["request_id"])
+	})
+
+	t.Run("Replaces Invalid Request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/reviews", nil)
+		req.Header.Set("X-Request-ID", strings.Repeat("x", 200))
+		resp := serve(req)
+
+		assert.Len(t, resp.Header().Get("X-Request-ID"), 32)
+	})
+
+	t.Run("Unmatched Route", func(t *testing.T) {
+		serve(httptest.NewRequest("GET", "/nowhere", nil))
+
+		assert.Equal(t, "unmatched", logLine(t)["route"])
+	})
+
+	t.Run("Skips Probes", func(t *testing.T) {
+		resp := serve(httptest.NewRequest("GET", "/healthz", nil))
+
+		assert.NotEmpty(t, resp.Header().Get("X-Request-ID"))
+		assert.Empty(t, out.String())
+	})
+}
+
+func TestModeratorAuth(t *testing.T) {
+	srv, err := server.NewServer(testConfig(t))
+	require.NoError(t, err)
+
+	serve := func(method string, path string, auth func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		auth(req)
+		resp := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(resp, req)
+		return resp
+	}
+	anonymous := func(r *http.Request) {}
+
+	for name, tc := range map[string]struct {
+		auth   func(r *http.Request)
+		status int
+	}{
+		"Anonymous":       {anonymous, http.StatusUnauthorized},
+		"API Key":         {func(r *http.Request) { r.Header.Set("X-API-Key", moderatorKey) }, http.StatusOK},
+		"Wrong API Key":   {func(r *http.Request) { r.Header.Set("X-API-Key", "guess") }, http.StatusUnauthorized},
+		"Basic Auth":      {func(r *http.Request) { r.SetBasicAuth("moderator", moderatorKey) }, http.StatusOK},
+		"Wrong Password":  {func(r *http.Request) { r.SetBasicAuth("moderator", "guess") }, http.StatusUnauthorized},
+		"Someone Else":    {func(r *http.Request) { r.SetBasicAuth("somchai", moderatorKey) }, http.StatusUnauthorized},
+		"Key As Username": {func(r *http.Request) { r.SetBasicAuth(moderatorKey, "") }, http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, path := range []string{"/moderation", "/admin/keywords", "/admin/duplicates"} {
+				resp := serve("GET", path, tc.auth)
+				assert.Equal(t, tc.status, resp.Code, path)
+				if tc.status == http.StatusUnauthorized {
+					assert.Contains(t, resp.Header().Get("WWW-Authenticate"), "Basic")
+				}
+			}
+		})
+	}
+
+	t.Run("Writes Need A Moderator", func(t *testing.T) {
+		for _, path := range []string{"/moderation/1/approve", "/moderation/1/reject", "/moderation/1/annotate", "/admin/keywords", "/admin/keywords/scan", "/admin/duplicates/merge"} {
+			assert.Equal(t, http.StatusUnauthorized, serve("POST", path, anonymous).Code, path)
+		}
+	})
+
+	t.Run("Writes Must Be JSON", func(t *testing.T) {
+		moderator := func(r *http.Request) { r.Header.Set("X-API-Key", moderatorKey) }
+		for _, path := range []string{"/moderation/1/approve", "/moderation/1/reject", "/moderation/1/annotate", "/admin/keywords", "/admin/keywords/scan", "/admin/duplicates/merge"} {
+			assert.Equal(t, http.StatusUnsupportedMediaType, serve("POST", path, moderator).Code, path)
+		}
+
+		form := func(r *http.Request) {
+			moderator(r)
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		assert.Equal(t, http.StatusUnsupportedMediaType, serve("POST", "/moderation/1/approve", form).Code)
+
+		json := func(r *http.Request) {
+			moderator(r)
+			r.Header.Set("Content-Type", "application/json; charset=utf-8")
+		}
+		assert.NotEqual(t, http.StatusUnsupportedMediaType, serve("POST", "/moderation/1/approve", json).Code)
+	})
+
+	t.Run("Deleting Needs A Moderator", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve("DELETE", "/reviews/1", anonymous).Code)
+
+		moderator := func(r *http.Request) { r.Header.Set("X-API-Key", moderatorKey) }
+		assert.Equal(t, http.StatusUnprocessableEntity, serve("DELETE", "/reviews/1", moderator).Code)
+	})
+
+	t.Run("Public Pages Stay Open", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("GET", "/reviews", anonymous).Code)
+	})
+
+	t.Run("Closed Without Keys", func(t *testing.T) {
+		cfg := testConfig(t)
+		cfg.ModeratorKeys = ""
+		closed, err := server.NewServer(cfg)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/moderation", nil)
+		req.SetBasicAuth("moderator", "")
+		resp := httptest.NewRecorder()
+		closed.Handler().ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestTracing(t *testing.T) {
+	previous := logging.Default()
+	logging.SetDefault(logging.New(io.Discard, logging.LevelInfo))
+	defer logging.SetDefault(previous)
+
+	cfg := testConfig(t)
+	cfg.TraceOutput = filepath.Join(t.TempDir(), "traces.jsonl")
+
+	srv, err := server.NewServer(cfg)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/reviews", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+
+	data, err := os.ReadFile(cfg.TraceOutput)
+	require.NoError(t, err)
+
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+	}
+	spans := map[string]span{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var traces struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &traces), line)
+		s := traces.ResourceSpans[0].ScopeSpans[0].Spans[0]
+		spans[s.Name] = s
+	}
+
+	request, ok := spans["GET /reviews"]
+	require.True(t, ok, "spans: %v", spans)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", request.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", request.ParentSpanID)
+
+	for _, name := range []string{"review.List", "template reviews.html"} {
+		if assert.Contains(t, spans, name) {
+			assert.Equal(t, request.TraceID, spans[name].TraceID, name)
+			assert.Equal(t, request.SpanID, spans[name].ParentSpanID, name)
+		}
+	}
+	assert.Len(t, spans, 3, "the probe isn't traced")
+}
+
+func TestRateLimiting(t *testing.T) {
+	previous := logging.Default()
+	logging.SetDefault(logging.New(io.Discard, logging.LevelInfo))
+	defer logging.SetDefault(previous)
+
+	cfg := testConfig(t)
+	cfg.RateLimits = filepath.Join(t.TempDir(), "ratelimit.json")
+	require.NoError(t, os.WriteFile(cfg.RateLimits, []byte(`{
+		"default": {"requests": 100, "per": "1m"},
+		"routes": {"GET /reviews/{reviewID}": {"requests": 1, "per": "1m"}}
+	}`), 0644))
+	cfg.RateLimitDB = filepath.Join(t.TempDir(), "ratelimit.db")
+
+	srv, err := server.NewServer(cfg)
+	require.NoError(t, err)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	resp := serve(httptest.NewRequest("GET", "/reviews/1", nil))
+	assert.NotEqual(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", resp.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", resp.Header().Get("RateLimit-Reset"))
+
+	resp = serve(httptest.NewRequest("GET", "/reviews/2", nil))
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	assert.Equal(t, "Too many requests", resp.Body.String())
+
+	inThai := httptest.NewRequest("GET", "/reviews/2", nil)
+	inThai.Header.Set("Accept-Language", "th")
+	assert.Equal(t, "มีคำขอมากเกินไป โปรดลองใหม่ภายหลัง", serve(inThai).Body.String())
+
+	for _, key := range []string{"made-up-1", "made-up-2"} {
+		withKey := httptest.NewRequest("GET", "/reviews/2", nil)
+		withKey.Header.Set("X-API-Key", key)
+		assert.Equal(t, http.StatusTooManyRequests, serve(withKey).Code, "unknown keys count against the IP")
+	}
+	withBasic := httptest.NewRequest("GET", "/reviews/2", nil)
+	withBasic.SetBasicAuth("somebody", "anything")
+	assert.Equal(t, http.StatusTooManyRequests, serve(withBasic).Code, "unknown users count against the IP")
+
+	withKey := httptest.NewRequest("GET", "/reviews/2", nil)
+	withKey.Header.Set("X-API-Key", moderatorKey)
+	assert.NotEqual(t, http.StatusTooManyRequests, serve(withKey).Code, "moderators have their own bucket")
+
+	resp = serve(httptest.NewRequest("GET", "/reviews", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "99", resp.Header().Get("RateLimit-Remaining"))
+
+	resp = serve(httptest.NewRequest("GET", "/healthz", nil))
+	assert.Empty(t, resp.Header().Get("RateLimit-Limit"), "probes aren't limited")
+
+	resp = serve(httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusOK, resp.Code, "readiness covers the rate limit database")
+}
+
+func TestPageCache(t *testing.T) {
+	previous := logging.Default()
+	logging.SetDefault(logging.New(io.Discard, logging.LevelInfo))
+	defer logging.SetDefault(previous)
+
+	srv, err := server.NewServer(testConfig(t))
+	require.NoError(t, err)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+	cacheRequests := func(result string) string {
+		resp := serve(httptest.NewRequest("GET", "/metrics", nil))
+		for _, line := range strings.Split(resp.Body.String(), "\n") {
+			if strings.HasPrefix(line, `food_review_page_cache_requests_total{route="/reviews",result="`+result+`"}`) {
+				return line[strings.LastIndex(line, " ")+1:]
+			}
+		}
+		return "0"
+	}
+
+	first := serve(httptest.NewRequest("GET", "/reviews", nil))
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, first.Header().Get("Last-Modified"))
+	assert.Equal(t, "no-cache", first.Header().Get("Cache-Control"))
+	assert.Equal(t, "text/html", first.Header().Get("Content-Type"))
+
+	second := serve(httptest.NewRequest("GET", "/reviews", nil))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, etag, second.Header().Get("ETag"))
+	assert.Equal(t, "1", cacheRequests("hit"))
+
+	t.Run("If-None-Match", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/reviews", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp := serve(req)
+
+		assert.Equal(t, http.StatusNotModified, resp.Code)
+		assert.Empty(t, resp.Body.String())
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/reviews", nil)
+		req.Header.Set("If-Modified-Since", first.Header().Get("Last-Modified"))
+
+		assert.Equal(t, http.StatusNotModified, serve(req).Code)
+	})
+
+	t.Run("Errors Aren't Cached", func(t *testing.T) {
+		serve(httptest.NewRequest("GET", "/reviews/1", nil))
+		resp := serve(httptest.NewRequest("GET", "/reviews/1", nil))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		assert.Empty(t, resp.Header().Get("ETag"))
+	})
+
+	t.Run("Writes Invalidate", func(t *testing.T) {
+		misses := cacheRequests("miss")
+
+		req := httptest.NewRequest("POST", "/reviews", strings.NewReader(`{"review": "The khao soi was rich and fragrant"}`))
+		require.Equal(t, http.StatusCreated, serve(req).Code)
+
+		assert.Equal(t, http.StatusOK, serve(httptest.NewRequest("GET", "/reviews", nil)).Code)
+		assert.NotEqual(t, misses, cacheRequests("miss"))
+	})
+}
+
+func TestErrorPages(t *testing.T) {
+	previous := logging.Default()
+	logging.SetDefault(logging.New(io.Discard, logging.LevelInfo))
+	defer logging.SetDefault(previous)
+
+	srv, err := server.NewServer(testConfig(t))
+	require.NoError(t, err)
+
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		recorder := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+	const browser = "text/html,application/xhtml+xml,*/*;q=0.8"
+
+	t.Run("Not Found", func(t *testing.T) {
+		resp := serve("/no-such-page", browser)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Body.String(), "<h1>Not Found</h1>")
+		assert.Contains(t, resp.Body.String(), `<header class="site">`, "error pages use the layout")
+	})
+
+	t.Run("Unprocessable", func(t *testing.T) {
+		resp := serve("/reviews/999", browser)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		assert.Contains(t, resp.Body.String(), "No Review with this ID")
+	})
+
+	t.Run("Plain For Scripts", func(t *testing.T) {
+		resp := serve("/reviews/999", "*/*")
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		assert.Equal(t, "No Review with this ID", resp.Body.String())
+	})
+
+	t.Run("Other Errors Pass Through", func(t *testing.T) {
+		resp := serve("/reviews/abc", browser)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, "Invalid ID", resp.Body.String())
+	})
+
+	t.Run("Server Error", func(t *testing.T) {
+		// A page failing part way through rendering stands in for any server
+		// error.
+		assets := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(assets, "template"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(assets, "template", "reviews.html"),
+			[]byte(`{{ .NoSuchField }}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(assets, "template", "error.html"),
+			[]byte(`<p>{{ .Message }}</p><p>{{ .RequestID }}</p>`), 0644))
+
+		cfg := testConfig(t)
+		cfg.AssetsDir = assets
+		srv, err := server.NewServer(cfg)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/reviews", nil)
+		req.Header.Set("Accept", browser)
+		req.Header.Set("X-Request-ID", "broken-page")
+		resp := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Contains(t, resp.Body.String(), "<p>broken-page</p>")
+		assert.NotContains(t, resp.Body.String(), "NoSuchField", "the error itself stays in the logs")
+	})
+}
+
+func TestLocalization(t *testing.T) {
+	previous := logging.Default()
+	logging.SetDefault(logging.New(io.Discard, logging.LevelInfo))
+	defer logging.SetDefault(previous)
+
+	srv, err := server.NewServer(testConfig(t))
+	require.NoError(t, err)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+	inThai := func(path string) *http.Request {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Language", "th-TH,th;q=0.9,en;q=0.8")
+		return req
+	}
+
+	english := serve(httptest.NewRequest("GET", "/reviews", nil))
+	assert.Equal(t, "en", english.Header().Get("Content-Language"))
+	assert.Contains(t, english.Body.String(), `<html lang="en">`)
+	assert.Contains(t, english.Body.String(), "<h1>Reviews</h1>")
+
+	thai := serve(inThai("/reviews"))
+	assert.Equal(t, "th", thai.Header().Get("Content-Language"))
+	assert.Equal(t, []string{"Accept-Language", "Cookie"}, thai.Header().Values("Vary"))
+	assert.Contains(t, thai.Body.String(), `<html lang="th">`)
+	assert.Contains(t, thai.Body.String(), "<h1>รีวิว</h1>", "the cached English page isn't served")
+	assert.NotEqual(t, english.Header().Get("ETag"), thai.Header().Get("ETag"))
+
+	t.Run("Choosing A Language", func(t *testing.T) {
+		resp := serve(httptest.NewRequest("GET", "/reviews?lang=th", nil))
+		assert.Equal(t, "th", resp.Header().Get("Content-Language"))
+
+		cookies := resp.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, "lang", cookies[0].Name)
+			assert.Equal(t, "th", cookies[0].Value)
+
+			req := httptest.NewRequest("GET", "/stats", nil)
+			req.AddCookie(cookies[0])
+			assert.Equal(t, "th", serve(req).Header().Get("Content-Language"))
+		}
+	})
+
+	t.Run("Error Messages", func(t *testing.T) {
+		resp := serve(inThai("/reviews/abc"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, "รหัสไม่ถูกต้อง", resp.Body.String())
+
+		req := inThai("/no-such-page")
+		req.Header.Set("Accept", "text/html")
+		resp = serve(req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "ไม่พบหน้าที่คุณต้องการ")
+
+		resp = serve(inThai("/moderation"))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Equal(t, "กรุณาเข้าสู่ระบบในฐานะผู้ดูแล", resp.Body.String())
+
+		req = httptest.NewRequest("POST", "/moderation/1/approve", nil)
+		req.Header.Set("Accept-Language", "th")
+		req.Header.Set("X-API-Key", moderatorKey)
+		resp = serve(req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+		assert.Equal(t, "คำขอต้องส่งเป็น JSON", resp.Body.String())
+	})
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"food-review/pkg/i18n"
+	"food-review/pkg/logging"
+	"food-review/pkg/ratelimit"
+	"food-review/pkg/route"
+	"food-review/pkg/web"
+)
+
+// rateLimited is mux middleware spending a request of the client's budget
+// for the matched route. Every response carries the RateLimit-* headers of
+// the IETF draft; refused requests get 429 and Retry-After. Probes and static
+// files aren't limited.
+func rateLimited(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if probePaths[r.URL.Path] || strings.HasPrefix(r.URL.Path, web.StaticPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision, err := limiter.Allow(rateLimitClient(r), r.Method, routeTemplate(r))
+			if err != nil {
+				// A broken store shouldn't take the site down with it.
+				logging.FromContext(r.Context()).Error("rate limit", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+			if !decision.Allowed {
+				header.Set("Retry-After", ceilSeconds(decision.RetryAfter))
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(i18n.FromContext(r.Context()).T("Too many requests")))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitClient identifies who a request counts against: the moderator who
+// made it, else the client IP. Credentials that didn't authenticate count
+// for nothing, so made-up keys neither escape the client's budget nor fill
+// the rate limit store with buckets.
+func rateLimitClient(r *http.Request) string {
+	if name := route.ModeratorFromContext(r.Context()); name != "" {
+		return "user:" + name
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ceilSeconds formats d in whole seconds, rounding up so a client waiting
+// that long is never early.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
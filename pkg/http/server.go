@@ -1,63 +1,528 @@
 package http
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"food-review/pkg/config"
 	"food-review/pkg/db"
+	"food-review/pkg/i18n"
+	"food-review/pkg/logging"
+	"food-review/pkg/markdown"
+	"food-review/pkg/profanity"
+	"food-review/pkg/ratelimit"
 	"food-review/pkg/route"
+	"food-review/pkg/spam"
+	"food-review/pkg/store"
 	"food-review/pkg/template"
+	"food-review/pkg/tracing"
+	"food-review/pkg/version"
+	"food-review/pkg/web"
 
-	"github.com/gorilla/context"
+	gcontext "github.com/gorilla/context"
 	"github.com/gorilla/mux"
 )
 
-func initNewRouter() *mux.Router {
+const (
+	readTimeout  = 10 * time.Second
+	writeTimeout = 30 * time.Second
+	idleTimeout  = 2 * time.Minute
+	// shutdownTimeout bounds how long in-flight requests get to finish after
+	// a shutdown signal. It stays under the stop grace period in
+	// docker-compose.yml so the container isn't killed mid-drain.
+	shutdownTimeout = 10 * time.Second
+	// keywordScanInterval is how often the keyword candidates are rescanned
+	// while the server runs.
+	keywordScanInterval = 15 * time.Minute
+)
+
+// Server is the web server together with the databases and trace output it
+// owns.
+type Server struct {
+	httpServer *http.Server
+	databases  []ownedDatabase
+	metrics    *serverMetrics
+	// keywordScan ranks keyword candidates in the background while serving.
+	keywordScan *route.KeywordScan
+	// traceFile is the trace output when it is a file rather than stdout.
+	traceFile io.Closer
+}
+
+// database is what db.ReviewDB, db.DictionaryDB and db.PostgresDB have in
+// common.
+type database interface {
+	io.Closer
+	GetDB() *sql.DB
+}
+
+// ownedDatabase is a database the server opened, named as in the readiness
+// and version reports.
+type ownedDatabase struct {
+	name       string
+	database   database
+	migrations []db.Migration
+}
+
+// NewServer opens the databases and loads everything the handlers need. The
+// databases stay open until the server shuts down.
+func NewServer(cfg config.Config) (*Server, error) {
+	server := &Server{metrics: newServerMetrics()}
+
+	reviews, dictionary, err := server.openStores(cfg)
+	if err != nil {
+		server.release()
+		return nil, err
+	}
+
+	limiter, err := server.openRateLimiter(cfg)
+	if err != nil {
+		server.release()
+		return nil, err
+	}
+
+	tracer, err := server.openTracer(cfg)
+	if err != nil {
+		server.release()
+		return nil, err
+	}
+
+	reviews = store.NewObservedReviewStore(reviews, server.metrics.observeStore)
+	dictionary = store.NewObservedDictionaryStore(dictionary, server.metrics.observeStore)
+
+	// Pages aren't cached while templates are read from disk, so edits to
+	// them show up straight away.
+	var pages *pageCache
+	if cfg.AssetsDir == "" {
+		pages = newPageCache(server.metrics)
+		reviews = store.NewObservedReviewStore(reviews, pages.invalidateOnWrite)
+		dictionary = store.NewObservedDictionaryStore(dictionary, pages.invalidateOnWrite)
+	}
+
+	server.keywordScan = &route.KeywordScan{Reviews: reviews, Dictionary: dictionary}
+
+	router, err := newRouter(cfg, reviews, dictionary, server.keywordScan, limiter, pages, server.healthHandler(), server.metrics)
+	if err != nil {
+		server.release()
+		return nil, err
+	}
+
+	// Tracing wraps logging so request log lines carry the trace ID.
+	handler := withRequestLogging(logging.Default(), router)
+	if tracer != nil {
+		handler = withTracing(tracer, handler)
+	}
+
+	server.httpServer = &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      gcontext.ClearHandler(handler),
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+
+	return server, nil
+}
+
+// openStores opens Postgres when the config has a DSN and the SQLite files
+// otherwise.
+func (s *Server) openStores(cfg config.Config) (store.ReviewStore, store.DictionaryStore, error) {
+	if cfg.UsePostgres() {
+		postgresDB := db.NewPostgresDB(cfg.PostgresDSN)
+		s.databases = append(s.databases, ownedDatabase{"postgres", postgresDB, postgresDB.Migrations})
+		if err := postgresDB.Init(); err != nil {
+			return nil, nil, err
+		}
+		reviews := store.NewPostgresReviewStore(postgresDB.GetDB())
+		dictionary := store.NewPostgresDictionaryStore(postgresDB.GetDB())
+		if err := backfill(reviews.Backfill, dictionary); err != nil {
+			return nil, nil, err
+		}
+		return reviews, dictionary, nil
+	}
+
+	reviewDB := db.NewReviewDB(cfg.ReviewDB)
+	s.databases = append(s.databases, ownedDatabase{"review", reviewDB, reviewDB.Migrations})
+	if err := reviewDB.Init(); err != nil {
+		return nil, nil, err
+	}
+
+	dictionaryDB := db.NewDictionaryDB(cfg.DictionaryDB)
+	s.databases = append(s.databases, ownedDatabase{"dictionary", dictionaryDB, dictionaryDB.Migrations})
+	if err := dictionaryDB.Init(); err != nil {
+		return nil, nil, err
+	}
+
+	reviews := store.NewSQLiteReviewStore(reviewDB.GetDB())
+	dictionary := store.NewSQLiteDictionaryStore(dictionaryDB.GetDB())
+	if err := backfill(reviews.Backfill, dictionary); err != nil {
+		return nil, nil, err
+	}
+	return reviews, dictionary, nil
+}
+
+// backfill fills in what the migrations just applied left to Go, logging
+// each backfill that ran.
+func backfill(run func(keywords []string) ([]string, error), dictionary store.DictionaryStore) error {
+	keywords, err := dictionary.List()
+	if err != nil {
+		return err
+	}
+
+	ran, err := run(keywords)
+	for _, name := range ran {
+		logging.Default().Info("backfilled", "backfill", name)
+	}
+	return err
+}
+
+// openRateLimiter keeps the rate limit buckets in the configured SQLite file,
+// or in memory when there is none.
+func (s *Server) openRateLimiter(cfg config.Config) (*ratelimit.Limiter, error) {
+	rules, err := ratelimit.LoadRules(cfg.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.RateLimitDB == "" {
+		return ratelimit.NewLimiter(rules, ratelimit.NewMemoryStore()), nil
+	}
+
+	rateLimitDB := db.NewRateLimitDB(cfg.RateLimitDB)
+	s.databases = append(s.databases, ownedDatabase{"ratelimit", rateLimitDB, rateLimitDB.Migrations})
+	if err := rateLimitDB.Init(); err != nil {
+		return nil, err
+	}
+	return ratelimit.NewLimiter(rules, ratelimit.NewSQLiteStore(rateLimitDB.GetDB())), nil
+}
+
+// openTracer returns a tracer writing to the configured trace output, or nil
+// when tracing is off.
+func (s *Server) openTracer(cfg config.Config) (*tracing.Tracer, error) {
+	var out io.Writer
+	switch cfg.TraceOutput {
+	case "":
+		return nil, nil
+	case "stdout":
+		out = os.Stdout
+	default:
+		file, err := os.OpenFile(cfg.TraceOutput, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		s.traceFile = file
+		out = file
+	}
+
+	tracer := tracing.NewTracer(tracing.NewOTLPJSONExporter(out, "food-review"))
+	tracer.OnError = func(err error) {
+		logging.Default().Error("export span", "error", err)
+	}
+	return tracer, nil
+}
+
+// healthHandler checks that every database answers and has all migrations
+// applied, and reports the schema versions the binary was built with.
+func (s *Server) healthHandler() *route.HealthHandler {
+	checks := map[string]route.ReadinessCheck{}
+	schema := map[string]int{}
+	for _, owned := range s.databases {
+		owned := owned
+		checks[owned.name+"_db"] = func() error {
+			handle := owned.database.GetDB()
+			if err := handle.Ping(); err != nil {
+				return err
+			}
+			return db.CheckSchemaCurrent(handle, owned.migrations)
+		}
+		schema[owned.name] = db.LatestVersion(owned.migrations)
+	}
+
+	return &route.HealthHandler{
+		Checks:  checks,
+		Version: version.Get(schema),
+	}
+}
+
+func newRouter(
+	cfg config.Config,
+	reviews store.ReviewStore,
+	dictionary store.DictionaryStore,
+	candidates *route.KeywordScan,
+	limiter *ratelimit.Limiter,
+	pages *pageCache,
+	health *route.HealthHandler,
+	serverMetrics *serverMetrics,
+) (http.Handler, error) {
+	moderatorKeys, err := cfg.Moderators()
+	if err != nil {
+		return nil, err
+	}
+	if len(moderatorKeys) == 0 {
+		logging.Default().Warn("no moderator_keys set; the moderation and admin pages are closed")
+	}
+
 	newRouter := mux.NewRouter()
+	// Rate limiting comes after instrument so refused requests are counted.
+	newRouter.Use(recordRoute, serverMetrics.instrument, authenticated(newModerators(moderatorKeys)), rateLimited(limiter))
 
-	tmpl := &template.Template{}
-	if err := tmpl.LoadTemplates("template/*.html"); err != nil {
-		log.Fatal(err)
+	profanityFilter, err := profanity.LoadFilter(cfg.Profanity)
+	if err != nil {
+		return nil, err
 	}
-	var templater template.Templater = tmpl
 
-	reviewDB := db.InitReviewDB()
-	var reviewDBOpener db.ReviewDBOpener = reviewDB
-	dictionaryDB := db.InitDictionaryDB()
-	var dictionaryDBOpener db.DictionaryDBOpener = dictionaryDB
+	assetsFS := web.Embedded()
+	if cfg.AssetsDir != "" {
+		assetsFS = os.DirFS(cfg.AssetsDir)
+	}
+	assets, err := web.NewAssets(assetsFS, cfg.AssetsDir != "")
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := i18n.LoadCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reviews are Markdown, with profanity masked in the text but never in
+	// the markup.
+	reviewMarkdown := &markdown.Renderer{Text: profanityFilter.Render}
+
+	tmpl := &template.Template{
+		Funcs: map[string]interface{}{
+			"clean":  profanityFilter.Render,
+			"static": assets.Path,
+			"markdown": func(source string) htmltemplate.HTML {
+				return htmltemplate.HTML(reviewMarkdown.Render(source))
+			},
+			"markdownInline": func(source string) htmltemplate.HTML {
+				return htmltemplate.HTML(reviewMarkdown.RenderInline(source))
+			},
+		},
+		Reload:  cfg.AssetsDir != "",
+		Catalog: catalog,
+	}
+	if err := tmpl.LoadTemplatesFS(assetsFS, web.TemplatePattern, web.SharedTemplatePatterns...); err != nil {
+		return nil, err
+	}
+	var templater template.Templater = &timedTemplater{next: tmpl, duration: serverMetrics.templateDuration}
+	health.Checks["templates"] = func() error {
+		if !tmpl.Loaded() {
+			return errors.New("templates not loaded")
+		}
+		return nil
+	}
+
+	spamRules, err := spam.LoadRules(cfg.SpamRules)
+	if err != nil {
+		return nil, err
+	}
 
 	handler := &route.Handler{
-		Template:     templater,
-		ReviewDB:     reviewDBOpener,
-		DictionaryDB: dictionaryDBOpener,
+		Template:   templater,
+		Reviews:    reviews,
+		Dictionary: dictionary,
+		Spam:       spam.NewChecker(spamRules),
+		Profanity:  profanityFilter,
+		Markdown:   reviewMarkdown,
+		Candidates: candidates,
 	}
 
+	// The probes are registered first so no other route can shadow them.
+	newRouter.HandleFunc("/healthz", health.Healthz).
+		Methods("GET")
+	newRouter.HandleFunc("/readyz", health.Readyz).
+		Methods("GET")
+	newRouter.HandleFunc("/version", health.GetVersion).
+		Methods("GET")
+	newRouter.Handle("/metrics", serverMetrics.registry.Handler()).
+		Methods("GET")
+
+	newRouter.PathPrefix(web.StaticPrefix).Handler(assets).
+		Methods("GET", "HEAD")
+
 	newRouter.HandleFunc("/", handler.Index).
 		Methods("GET")
-	newRouter.HandleFunc("/reviews", handler.GetReviewsByKeyword).
+	newRouter.HandleFunc("/reviews", pages.cached(handler.GetReviewsByKeyword)).
 		Queries("query", "{keyword}").
 		Methods("GET")
-	newRouter.HandleFunc("/reviews", handler.GetAllReviews).
+	newRouter.HandleFunc("/reviews", pages.cached(handler.GetAllReviews)).
 		Methods("GET")
-	newRouter.HandleFunc("/reviews/{reviewID}", handler.GetReview).
+	newRouter.HandleFunc("/reviews/{reviewID}", pages.cached(handler.GetReview)).
 		Methods("GET")
 	newRouter.HandleFunc("/reviews/{reviewID}/edit", handler.AccessReviewEdit).
 		Methods("GET")
 	newRouter.HandleFunc("/reviews/{reviewID}", handler.EditReview).
 		Methods("PUT")
+	newRouter.HandleFunc("/reviews/{reviewID}", moderatorOnly(handler.DeleteReview)).
+		Methods("DELETE")
+	newRouter.HandleFunc("/reviews", handler.CreateReview).
+		Methods("POST")
+	newRouter.HandleFunc("/reviews/{reviewID}/flag", handler.FlagReview).
+		Methods("POST")
+	newRouter.HandleFunc("/reviews/preview", handler.PreviewReview).
+		Methods("POST")
 
-	return newRouter
+	newRouter.HandleFunc("/feeds/reviews.atom", pages.cached(handler.GetReviewsAtom)).
+		Methods("GET")
+	newRouter.HandleFunc("/feeds/reviews.rss", pages.cached(handler.GetReviewsRSS)).
+		Methods("GET")
+	newRouter.HandleFunc("/feeds/search.atom", pages.cached(handler.GetSearchAtom)).
+		Methods("GET")
+
+	newRouter.HandleFunc("/sentiment", pages.cached(handler.GetSentimentBreakdown)).
+		Methods("GET")
+	newRouter.HandleFunc("/stats", pages.cached(handler.GetStats)).
+		Methods("GET")
+	newRouter.HandleFunc("/stats/keywords/{keyword}", pages.cached(handler.GetKeywordAspects)).
+		Methods("GET")
+
+	// Moderation and admin pages need a moderator's key, and their posts
+	// must be JSON.
+	newRouter.HandleFunc("/admin/keywords", moderatorOnly(handler.GetKeywordCandidates)).
+		Methods("GET")
+	newRouter.HandleFunc("/admin/keywords", moderatorOnly(jsonOnly(handler.AddKeyword))).
+		Methods("POST")
+	newRouter.HandleFunc("/admin/keywords/scan", moderatorOnly(jsonOnly(handler.ScanKeywordCandidates))).
+		Methods("POST")
+
+	newRouter.HandleFunc("/admin/duplicates", moderatorOnly(handler.GetDuplicateClusters)).
+		Methods("GET")
+	newRouter.HandleFunc("/admin/duplicates/merge", moderatorOnly(jsonOnly(handler.MergeReviews))).
+		Methods("POST")
+
+	newRouter.HandleFunc("/moderation", moderatorOnly(handler.GetModerationQueue)).
+		Methods("GET")
+	newRouter.HandleFunc("/moderation/{reviewID}/approve", moderatorOnly(jsonOnly(handler.ApproveReview))).
+		Methods("POST")
+	newRouter.HandleFunc("/moderation/{reviewID}/reject", moderatorOnly(jsonOnly(handler.RejectReview))).
+		Methods("POST")
+	newRouter.HandleFunc("/moderation/{reviewID}/annotate", moderatorOnly(jsonOnly(handler.AnnotateReview))).
+		Methods("POST")
+
+	return localized(catalog, withErrorPages(templater, newRouter)), nil
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
 }
 
-func StartServer() {
-	newRouter := initNewRouter()
-	http.Handle("/", newRouter)
+// ListenAndServe listens on the configured address and serves until ctx is
+// done.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		s.release()
+		return err
+	}
 
-	fmt.Println("Someone has entered your website")
+	return s.Serve(ctx, listener)
+}
+
+// Serve handles requests on listener, and rescans the keyword candidates
+// every keywordScanInterval, until ctx is done. It then stops accepting
+// connections, waits up to shutdownTimeout for in-flight requests and closes
+// the databases and trace output. It returns nil after a clean shutdown.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- s.httpServer.Serve(listener)
+	}()
+
+	scanCtx, cancelScan := context.WithCancel(ctx)
+	scanned := make(chan struct{})
+	go func() {
+		defer close(scanned)
+		s.keywordScan.Run(scanCtx, keywordScanInterval)
+	}()
+	// A scan in progress must finish before release closes its databases.
+	stopScan := func() {
+		cancelScan()
+		<-scanned
+	}
 
-	err := http.ListenAndServe(":5555", context.ClearHandler(http.DefaultServeMux))
+	select {
+	case err := <-served:
+		stopScan()
+		s.release()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
 	if err != nil {
-		log.Fatal(err)
+		// Requests still running past the deadline are cut off rather than
+		// left holding the databases open.
+		s.httpServer.Close()
+	}
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
 	}
+
+	stopScan()
+	if closeErr := s.release(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// release closes every database opened so far and the trace file, and
+// returns the first error.
+func (s *Server) release() error {
+	var firstErr error
+	for _, owned := range s.databases {
+		if err := owned.database.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.databases = nil
+
+	if s.traceFile != nil {
+		if err := s.traceFile.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		s.traceFile = nil
+	}
+
+	return firstErr
+}
+
+// StartServer runs the server until it receives SIGINT or SIGTERM, logging
+// to stderr at the configured level.
+func StartServer(cfg config.Config) error {
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	logger := logging.New(os.Stderr, level)
+	logging.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("listening", "addr", cfg.Addr)
+	err = server.ListenAndServe(ctx)
+	if err == nil {
+		logger.Info("shut down")
+	}
+
+	return err
 }
@@ -0,0 +1,235 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/config"
+	server "food-review/pkg/http"
+)
+
+// moderatorKey is the key of the "moderator" testConfig sets up.
+const moderatorKey = "secret"
+
+func testConfig(t *testing.T) config.Config {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Addr = "127.0.0.1:0"
+	cfg.ReviewDB = filepath.Join(dir, "review.db")
+	cfg.DictionaryDB = filepath.Join(dir, "dictionary.db")
+	cfg.ModeratorKeys = "moderator:" + moderatorKey
+	return cfg
+}
+
+// approve publishes a review the way a moderator would.
+func approve(t *testing.T, srv *server.Server, reviewID string) {
+	req := httptest.NewRequest("POST", "/moderation/"+reviewID+"/approve", nil)
+	req.Header.Set("X-API-Key", moderatorKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+}
+
+func TestServer(t *testing.T) {
+	t.Run("Serves Until Cancelled", func(t *testing.T) {
+		srv, err := server.NewServer(testConfig(t))
+		require.NoError(t, err)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() { served <- srv.Serve(ctx, listener) }()
+
+		for _, path := range []string{"/reviews", "/healthz", "/readyz", "/version"} {
+			resp, err := http.Get("http://" + listener.Addr().String() + path)
+			if assert.NoError(t, err, path) {
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+			}
+		}
+
+		resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+		if assert.NoError(t, err) {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Contains(t, string(body), `food_review_http_requests_total{method="GET",route="/reviews",code="200"} 1`)
+			assert.Contains(t, string(body), `food_review_template_render_duration_seconds_count{template="reviews.html"} 1`)
+			assert.Contains(t, string(body), `food_review_db_query_duration_seconds_count{store="review",operation="List"} 1`)
+		}
+
+		cancel()
+		select {
+		case err := <-served:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not shut down")
+		}
+
+		_, err = http.Get("http://" + listener.Addr().String() + "/reviews")
+		assert.Error(t, err)
+	})
+
+	t.Run("Static Assets", func(t *testing.T) {
+		srv, err := server.NewServer(testConfig(t))
+		require.NoError(t, err)
+
+		page := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, httptest.NewRequest("GET", "/reviews", nil))
+		stylesheet := regexp.MustCompile(`href="(/static/css/site\.[0-9a-f]+\.css)"`).FindStringSubmatch(page.Body.String())
+		require.Len(t, stylesheet, 2, page.Body.String())
+
+		resp := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(resp, httptest.NewRequest("GET", stylesheet[1], nil))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Cache-Control"), "immutable")
+	})
+
+	t.Run("Embedded Pages Render", func(t *testing.T) {
+		srv, err := server.NewServer(testConfig(t))
+		require.NoError(t, err)
+
+		for _, path := range []string{"/reviews", "/sentiment", "/stats", "/moderation", "/admin/keywords", "/admin/duplicates"} {
+			request := httptest.NewRequest("GET", path, nil)
+			request.Header.Set("X-API-Key", moderatorKey)
+			page := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(page, request)
+			assert.Equal(t, http.StatusOK, page.Code, path)
+			assert.Contains(t, page.Body.String(), `<header class="site">`, path)
+			assert.Contains(t, page.Body.String(), "</html>", path)
+		}
+	})
+
+	t.Run("Markdown Reviews", func(t *testing.T) {
+		srv, err := server.NewServer(testConfig(t))
+		require.NoError(t, err)
+
+		created := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(created, httptest.NewRequest("POST", "/reviews",
+			strings.NewReader(`{"review": "The **pad thai** was great\n\n- crispy\n- <img src=x onerror=alert(1)>"}`)))
+		require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+
+		page := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, httptest.NewRequest("GET", "/reviews/1", nil))
+		assert.Equal(t, http.StatusNotFound, page.Code, "pending reviews aren't public")
+
+		approve(t, srv, "1")
+		page = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, httptest.NewRequest("GET", "/reviews/1", nil))
+		assert.Contains(t, page.Body.String(), "<p>The <strong>pad thai</strong> was great</p>")
+		assert.Contains(t, page.Body.String(), "<li>&lt;img src=x onerror=alert(1)&gt;</li>")
+
+		edit := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(edit, httptest.NewRequest("GET", "/reviews/1/edit", nil))
+		assert.Contains(t, edit.Body.String(), "The **pad thai** was great", "the source is edited, not the HTML")
+	})
+
+	t.Run("Edit History", func(t *testing.T) {
+		srv, err := server.NewServer(testConfig(t))
+		require.NoError(t, err)
+
+		created := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(created, httptest.NewRequest("POST", "/reviews", strings.NewReader(`{"review": "Crispy pork belly"}`)))
+		require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+		assert.Contains(t, created.Body.String(), `"edited_count":0`)
+
+		// An edit goes back to the queue, and only approved reviews can be
+		// edited.
+		for _, content := range []string{"Crispy pork belly, great sauce", "Crispy pork belly, great sauce and rice"} {
+			approve(t, srv, "1")
+			edited := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(edited, httptest.NewRequest("PUT", "/reviews/1", strings.NewReader(`{"review": "`+content+`"}`)))
+			require.Equal(t, http.StatusOK, edited.Code, edited.Body.String())
+		}
+		pending := httptest.NewRecorder()
+		sneaky := httptest.NewRequest("PUT", "/reviews/1", strings.NewReader(`{"review": "Sneaky pork belly"}`))
+		sneaky.RemoteAddr = "198.51.100.7:1234" // a client with edits left in its budget
+		srv.Handler().ServeHTTP(pending, sneaky)
+		assert.Equal(t, http.StatusNotFound, pending.Code)
+
+		queue := httptest.NewRequest("GET", "/moderation", nil)
+		queue.Header.Set("X-API-Key", moderatorKey)
+		page := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, queue)
+		assert.Contains(t, page.Body.String(), "approved by moderator", "the audit trail names who acted")
+		approve(t, srv, "1")
+
+		page = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, httptest.NewRequest("GET", "/reviews/1", nil))
+		assert.Contains(t, page.Body.String(), "edited 2 times, last just now")
+	})
+
+	t.Run("Feeds", func(t *testing.T) {
+		srv, err := server.NewServer(testConfig(t))
+		require.NoError(t, err)
+
+		for path, contentType := range map[string]string{
+			"/feeds/reviews.atom": "application/atom+xml; charset=utf-8",
+			"/feeds/reviews.rss":  "application/rss+xml; charset=utf-8",
+		} {
+			for _, host := range []string{"food.example", "food.test"} {
+				request := httptest.NewRequest("GET", path, nil)
+				request.Host = host
+				resp := httptest.NewRecorder()
+				srv.Handler().ServeHTTP(resp, request)
+				assert.Equal(t, http.StatusOK, resp.Code, path)
+				assert.Equal(t, contentType, resp.Header().Get("Content-Type"), path)
+				assert.Contains(t, resp.Body.String(), `href="http://`+host+path+`"`, "cached per host")
+			}
+		}
+
+		search := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(search, httptest.NewRequest("GET", "/feeds/search.atom?query=nothing-like-it", nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, search.Code)
+
+		page := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, httptest.NewRequest("GET", "/reviews", nil))
+		assert.Contains(t, page.Body.String(), `<link rel="alternate" type="application/atom+xml" title="New reviews" href="/feeds/reviews.atom">`)
+	})
+
+	t.Run("Assets From Disk", func(t *testing.T) {
+		cfg := testConfig(t)
+		cfg.AssetsDir = "../web"
+
+		srv, err := server.NewServer(cfg)
+		require.NoError(t, err)
+
+		page := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(page, httptest.NewRequest("GET", "/reviews", nil))
+		assert.Contains(t, page.Body.String(), `href="/static/css/site.css"`)
+	})
+
+	t.Run("Missing Templates", func(t *testing.T) {
+		cfg := testConfig(t)
+		cfg.AssetsDir = t.TempDir()
+
+		_, err := server.NewServer(cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("Address In Use", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		cfg := testConfig(t)
+		cfg.Addr = listener.Addr().String()
+		srv, err := server.NewServer(cfg)
+		require.NoError(t, err)
+
+		assert.Error(t, srv.ListenAndServe(context.Background()))
+	})
+}
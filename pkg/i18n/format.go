@@ -0,0 +1,135 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// format is how a locale writes dates and numbers.
+type format struct {
+	months [12]string
+	// yearOffset is added to the Gregorian year; Thai dates use the
+	// Buddhist era, 543 years ahead.
+	yearOffset int
+	// date lays out the day, month name and year.
+	date    func(day int, month string, year int) string
+	group   string
+	decimal string
+}
+
+var formats = map[string]format{
+	"en": {
+		months: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		date: func(day int, month string, year int) string {
+			return month + " " + strconv.Itoa(day) + ", " + strconv.Itoa(year)
+		},
+		group:   ",",
+		decimal: ".",
+	},
+	"th": {
+		months:     [12]string{"ม.ค.", "ก.พ.", "มี.ค.", "เม.ย.", "พ.ค.", "มิ.ย.", "ก.ค.", "ส.ค.", "ก.ย.", "ต.ค.", "พ.ย.", "ธ.ค."},
+		yearOffset: 543,
+		date: func(day int, month string, year int) string {
+			return strconv.Itoa(day) + " " + month + " " + strconv.Itoa(year)
+		},
+		group:   ",",
+		decimal: ".",
+	},
+}
+
+func (p *Printer) format() format {
+	if p != nil {
+		if f, ok := formats[p.Locale]; ok {
+			return f
+		}
+	}
+	return formats[DefaultLocale]
+}
+
+// Date writes the day of t, such as "Jan 2, 2026" or "2 ม.ค. 2569".
+func (p *Printer) Date(t time.Time) string {
+	f := p.format()
+	return f.date(t.Day(), f.months[t.Month()-1], t.Year()+f.yearOffset)
+}
+
+// DateTime writes the day and the time of t to the minute.
+func (p *Printer) DateTime(t time.Time) string {
+	return p.Date(t) + " " + t.Format("15:04")
+}
+
+// Ago writes how long before now t was, such as "2 days ago", down to the
+// minute. A month or more back it gives the date instead.
+func (p *Printer) Ago(t time.Time, now time.Time) string {
+	elapsed := now.Sub(t)
+	switch {
+	case elapsed < time.Minute:
+		return p.T("just now")
+	case elapsed < 2*time.Minute:
+		return p.T("a minute ago")
+	case elapsed < time.Hour:
+		return p.T("%d minutes ago", int(elapsed/time.Minute))
+	case elapsed < 2*time.Hour:
+		return p.T("an hour ago")
+	case elapsed < 24*time.Hour:
+		return p.T("%d hours ago", int(elapsed/time.Hour))
+	case elapsed < 48*time.Hour:
+		return p.T("yesterday")
+	case elapsed < 30*24*time.Hour:
+		return p.T("%d days ago", int(elapsed/(24*time.Hour)))
+	}
+	return p.Date(t)
+}
+
+// Int writes n with its digits grouped in thousands.
+func (p *Printer) Int(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + groupDigits(digits, p.format().group)
+}
+
+// Number writes v rounded to decimals places, grouped like Int.
+func (p *Printer) Number(v float64, decimals int) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	f := p.format()
+
+	text := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	whole, fraction := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		whole, fraction = text[:i], text[i+1:]
+	}
+
+	sign := ""
+	if v < 0 && strings.Trim(text, "0.") != "" {
+		sign = "-"
+	}
+	result := sign + groupDigits(whole, f.group)
+	if fraction != "" {
+		result += f.decimal + fraction
+	}
+	return result
+}
+
+func groupDigits(digits, separator string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,229 @@
+// Package i18n translates the site's text and formats dates and numbers for
+// the reader's language. Messages are looked up by their English text, so
+// English needs no catalog entries and a missing translation falls back to
+// English rather than to a message ID.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is served when nothing the reader asks for is available.
+const DefaultLocale = "en"
+
+// LocaleParam is the query parameter and cookie choosing the language
+// explicitly.
+const LocaleParam = "lang"
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// catalogFile is the layout of a locales/<locale>.json file.
+type catalogFile struct {
+	// Name is the language's name in itself, for the language switcher.
+	Name     string            `json:"name"`
+	Messages map[string]string `json:"messages"`
+}
+
+// Catalog holds the translations of every supported locale.
+type Catalog struct {
+	locales  []string
+	names    map[string]string
+	messages map[string]map[string]string
+}
+
+// LoadCatalog reads the catalogs compiled into the binary.
+func LoadCatalog() (*Catalog, error) {
+	locales, err := fs.Sub(embedded, "locales")
+	if err != nil {
+		return nil, err
+	}
+	return LoadCatalogFS(locales)
+}
+
+// LoadCatalogFS reads one <locale>.json file per locale from fsys. There must
+// be one for DefaultLocale.
+func LoadCatalogFS(fsys fs.FS) (*Catalog, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{
+		names:    map[string]string{},
+		messages: map[string]map[string]string{},
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var parsed catalogFile
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", file, err)
+		}
+
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		catalog.locales = append(catalog.locales, locale)
+		catalog.names[locale] = parsed.Name
+		catalog.messages[locale] = parsed.Messages
+	}
+	if _, ok := catalog.messages[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("i18n: no catalog for the default locale %q", DefaultLocale)
+	}
+
+	// The default locale comes first, then the rest in name order.
+	sort.Slice(catalog.locales, func(i, j int) bool {
+		if catalog.locales[i] == DefaultLocale || catalog.locales[j] == DefaultLocale {
+			return catalog.locales[i] == DefaultLocale
+		}
+		return catalog.locales[i] < catalog.locales[j]
+	})
+	return catalog, nil
+}
+
+// Locales lists the supported locales, DefaultLocale first. A nil catalog
+// supports only DefaultLocale.
+func (c *Catalog) Locales() []string {
+	if c == nil {
+		return []string{DefaultLocale}
+	}
+	return c.locales
+}
+
+// Name is the locale's language named in itself, such as "ไทย".
+func (c *Catalog) Name(locale string) string {
+	if c == nil {
+		return locale
+	}
+	if name := c.names[locale]; name != "" {
+		return name
+	}
+	return locale
+}
+
+// Match returns the supported locale for a language tag such as "th-TH".
+func (c *Catalog) Match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, locale := range c.Locales() {
+		if locale == tag {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate picks the locale for a request: the lang query parameter, else
+// the lang cookie, else the best match in Accept-Language, else
+// DefaultLocale.
+func (c *Catalog) Negotiate(r *http.Request) string {
+	if locale, ok := c.Match(r.URL.Query().Get(LocaleParam)); ok {
+		return locale
+	}
+	if cookie, err := r.Cookie(LocaleParam); err == nil {
+		if locale, ok := c.Match(cookie.Value); ok {
+			return locale
+		}
+	}
+	for _, tag := range acceptedLanguages(r.Header.Get("Accept-Language")) {
+		if locale, ok := c.Match(tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// acceptedLanguages lists the tags of an Accept-Language header, most
+// preferred first. Tags with q=0 are left out.
+func acceptedLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	result := make([]string, len(tags))
+	for i, tag := range tags {
+		result[i] = tag.tag
+	}
+	return result
+}
+
+// Printer renders text in one locale.
+func (c *Catalog) Printer(locale string) *Printer {
+	p := &Printer{Locale: DefaultLocale}
+	if c == nil {
+		return p
+	}
+	if messages, ok := c.messages[locale]; ok {
+		p.Locale, p.messages = locale, messages
+	}
+	return p
+}
+
+// Printer translates messages and formats values for one locale. The zero
+// value and nil print English.
+type Printer struct {
+	Locale   string
+	messages map[string]string
+}
+
+// T translates message, written in English, and fills in args as
+// fmt.Sprintf would.
+func (p *Printer) T(message string, args ...interface{}) string {
+	if p != nil {
+		if translated, ok := p.messages[message]; ok && translated != "" {
+			message = translated
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p, the printer for the request's
+// locale.
+func NewContext(ctx context.Context, p *Printer) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the printer in ctx, or an English one.
+func FromContext(ctx context.Context) *Printer {
+	if p, ok := ctx.Value(contextKey{}).(*Printer); ok {
+		return p
+	}
+	return &Printer{Locale: DefaultLocale}
+}
@@ -0,0 +1,148 @@
+package i18n_test
+
+import (
+	"context"
+	"io/fs"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/i18n"
+	"food-review/pkg/web"
+)
+
+func testCatalog(t *testing.T) *i18n.Catalog {
+	catalog, err := i18n.LoadCatalogFS(fstest.MapFS{
+		"en.json": {Data: []byte(`{"name": "English", "messages": {}}`)},
+		"th.json": {Data: []byte(`{"name": "ไทย", "messages": {"Reviews": "รีวิว", "Page %d of %d": "หน้า %d จาก %d"}}`)},
+	})
+	require.NoError(t, err)
+	return catalog
+}
+
+func TestLoadCatalog(t *testing.T) {
+	t.Run("Embedded", func(t *testing.T) {
+		catalog, err := i18n.LoadCatalog()
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"en", "th"}, catalog.Locales())
+			assert.Equal(t, "ไทย", catalog.Name("th"))
+		}
+	})
+
+	t.Run("No Default Locale", func(t *testing.T) {
+		_, err := i18n.LoadCatalogFS(fstest.MapFS{"th.json": {Data: []byte(`{"messages": {}}`)}})
+		assert.ErrorContains(t, err, "default locale")
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := i18n.LoadCatalogFS(fstest.MapFS{"en.json": {Data: []byte(`{`)}})
+		assert.ErrorContains(t, err, "en.json")
+	})
+
+	t.Run("Templates Translated", func(t *testing.T) {
+		catalog, err := i18n.LoadCatalog()
+		require.NoError(t, err)
+		thai := catalog.Printer("th")
+
+		// Every literal message the templates translate has a Thai entry.
+		message := regexp.MustCompile(`\bT "([^"]+)"`)
+		err = fs.WalkDir(web.Embedded(), "template", func(name string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			data, err := fs.ReadFile(web.Embedded(), name)
+			if err != nil {
+				return err
+			}
+			for _, match := range message.FindAllStringSubmatch(string(data), -1) {
+				assert.NotEqual(t, match[1], thai.T(match[1]), "%s: %q", name, match[1])
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	catalog := testCatalog(t)
+
+	tests := []struct {
+		name           string
+		url            string
+		cookie         string
+		acceptLanguage string
+		want           string
+	}{
+		{"Nothing Asked", "/reviews", "", "", "en"},
+		{"Accept-Language", "/reviews", "", "th-TH,th;q=0.9,en;q=0.8", "th"},
+		{"Accept-Language Weights", "/reviews", "", "en;q=0.5, th", "th"},
+		{"Unsupported Falls Back", "/reviews", "", "ja-JP,fr;q=0.5", "en"},
+		{"Refused", "/reviews", "", "th;q=0, ja", "en"},
+		{"Cookie Over Header", "/reviews", "th", "en", "th"},
+		{"Query Over Cookie", "/reviews?lang=en", "th", "th", "en"},
+		{"Unsupported Query Ignored", "/reviews?lang=xx", "", "th", "th"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", test.url, nil)
+			if test.cookie != "" {
+				r.Header.Set("Cookie", "lang="+test.cookie)
+			}
+			if test.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", test.acceptLanguage)
+			}
+			assert.Equal(t, test.want, catalog.Negotiate(r))
+		})
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	catalog := testCatalog(t)
+	english, thai := catalog.Printer("en"), catalog.Printer("th")
+
+	t.Run("Translate", func(t *testing.T) {
+		assert.Equal(t, "รีวิว", thai.T("Reviews"))
+		assert.Equal(t, "หน้า 2 จาก 5", thai.T("Page %d of %d", 2, 5))
+		assert.Equal(t, "Page 2 of 5", english.T("Page %d of %d", 2, 5))
+		assert.Equal(t, "Stats", thai.T("Stats"), "missing translations fall back to English")
+		assert.Equal(t, "en", catalog.Printer("xx").Locale)
+	})
+
+	t.Run("Dates", func(t *testing.T) {
+		day := time.Date(2026, time.January, 2, 15, 4, 0, 0, time.UTC)
+		assert.Equal(t, "Jan 2, 2026", english.Date(day))
+		assert.Equal(t, "2 ม.ค. 2569", thai.Date(day), "Thai dates are in the Buddhist era")
+		assert.Equal(t, "2 ม.ค. 2569 15:04", thai.DateTime(day))
+	})
+
+	t.Run("Ago", func(t *testing.T) {
+		now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+		assert.Equal(t, "just now", english.Ago(now.Add(-30*time.Second), now))
+		assert.Equal(t, "a minute ago", english.Ago(now.Add(-90*time.Second), now))
+		assert.Equal(t, "5 minutes ago", english.Ago(now.Add(-5*time.Minute), now))
+		assert.Equal(t, "3 hours ago", english.Ago(now.Add(-3*time.Hour), now))
+		assert.Equal(t, "yesterday", english.Ago(now.Add(-30*time.Hour), now))
+		assert.Equal(t, "2 days ago", english.Ago(now.Add(-50*time.Hour), now))
+		assert.Equal(t, "Jan 2, 2026", english.Ago(time.Date(2026, time.January, 2, 15, 4, 0, 0, time.UTC), now))
+	})
+
+	t.Run("Numbers", func(t *testing.T) {
+		assert.Equal(t, "1,234,567", english.Int(1234567))
+		assert.Equal(t, "-1,000", thai.Int(-1000))
+		assert.Equal(t, "999", english.Int(999))
+		assert.Equal(t, "12,345.68", english.Number(12345.678, 2))
+		assert.Equal(t, "0.50", thai.Number(0.5, 2))
+		assert.Equal(t, "0.00", english.Number(-0.001, 2), "no negative zero")
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		ctx := i18n.NewContext(context.Background(), thai)
+		assert.Equal(t, "รีวิว", i18n.FromContext(ctx).T("Reviews"))
+		assert.Equal(t, "Reviews", i18n.FromContext(context.Background()).T("Reviews"))
+	})
+}
@@ -0,0 +1,176 @@
+// Package logging writes levelled, structured log lines as JSON. It follows
+// log/slog's levels and key/value calling convention so the code can move to
+// slog once the module targets Go 1.21.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+func (l Level) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// ParseLevel reads a level name such as "info", in any case.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// output is shared by a logger and everything derived from it with With, so
+// their lines never interleave.
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Logger writes one JSON object per line with the time, level, message and
+// any attributes, in the order given.
+type Logger struct {
+	out   *output
+	level Level
+	// attrs are encoded key/value pairs, each starting with a comma.
+	attrs []byte
+	// Now timestamps lines; tests replace it.
+	Now func() time.Time
+}
+
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{out: &output{w: w}, level: level, Now: time.Now}
+}
+
+var defaultLogger = New(os.Stderr, LevelInfo)
+
+// Default is the logger used where no other is at hand, such as outside a
+// request.
+func Default() *Logger {
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// With returns a logger that adds the key/value pairs in args to every line.
+func (l *Logger) With(args ...interface{}) *Logger {
+	with := *l
+	with.attrs = appendAttrs(append([]byte(nil), l.attrs...), args)
+	return &with
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Debug(msg string, args ...interface{}) { l.Log(LevelDebug, msg, args...) }
+func (l *Logger) Info(msg string, args ...interface{})  { l.Log(LevelInfo, msg, args...) }
+func (l *Logger) Warn(msg string, args ...interface{})  { l.Log(LevelWarn, msg, args...) }
+func (l *Logger) Error(msg string, args ...interface{}) { l.Log(LevelError, msg, args...) }
+
+// Log writes msg at level with the key/value pairs in args. Anything that
+// isn't a key followed by its value is logged under "!BADKEY", as slog does.
+func (l *Logger) Log(level Level, msg string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	line := bytes.NewBufferString(`{"time":`)
+	line.Write(encode(l.Now().UTC().Format(time.RFC3339Nano)))
+	line.WriteString(`,"level":`)
+	line.Write(encode(level.String()))
+	line.WriteString(`,"msg":`)
+	line.Write(encode(msg))
+	line.Write(l.attrs)
+	line.Write(appendAttrs(nil, args))
+	line.WriteString("}\n")
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w.Write(line.Bytes())
+}
+
+func appendAttrs(buf []byte, args []interface{}) []byte {
+	for len(args) > 0 {
+		var key string
+		var value interface{}
+		if k, ok := args[0].(string); ok && len(args) > 1 {
+			key, value, args = k, args[1], args[2:]
+		} else {
+			key, value, args = "!BADKEY", args[0], args[1:]
+		}
+
+		buf = append(buf, ',')
+		buf = append(buf, encode(key)...)
+		buf = append(buf, ':')
+		buf = append(buf, encode(value)...)
+	}
+	return buf
+}
+
+// encode marshals a value for a log line. Errors and durations get readable
+// forms, and anything json can't encode falls back to fmt.
+func encode(value interface{}) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return data
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, e.g. a logger tagged with the
+// request ID.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger in ctx, or Default.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default()
+}
@@ -0,0 +1,77 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/logging"
+)
+
+func newTestLogger(level logging.Level) (*logging.Logger, *bytes.Buffer) {
+	var out bytes.Buffer
+	logger := logging.New(&out, level)
+	logger.Now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	return logger, &out
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("JSON Lines", func(t *testing.T) {
+		logger, out := newTestLogger(logging.LevelInfo)
+
+		logger.With("request_id", "abc").Error("query failed", "error", errors.New("no such table"), "took", 1500*time.Millisecond)
+		logger.Info("listening", "addr", ":5555", "dangling")
+
+		assert.Equal(t, `{"time":"2024-03-01T12:00:00Z","level":"ERROR","msg":"query failed","request_id":"abc","error":"no such table","took":"1.5s"}
+{"time":"2024-03-01T12:00:00Z","level":"INFO","msg":"listening","addr":":5555","!BADKEY":"dangling"}
+`, out.String())
+	})
+
+	t.Run("Below Level", func(t *testing.T) {
+		logger, out := newTestLogger(logging.LevelWarn)
+
+		logger.Debug("store call")
+		logger.Info("request")
+		logger.Warn("request")
+
+		assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("\n")))
+		assert.False(t, logger.Enabled(logging.LevelInfo))
+	})
+
+	t.Run("With Leaves Parent Alone", func(t *testing.T) {
+		logger, out := newTestLogger(logging.LevelInfo)
+
+		logger.With("request_id", "abc")
+		logger.Info("listening")
+
+		assert.NotContains(t, out.String(), "request_id")
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]logging.Level{
+		"debug": logging.LevelDebug,
+		"INFO":  logging.LevelInfo,
+		"Warn":  logging.LevelWarn,
+		"error": logging.LevelError,
+	} {
+		level, err := logging.ParseLevel(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, want, level, name)
+		}
+	}
+
+	_, err := logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestContext(t *testing.T) {
+	logger, _ := newTestLogger(logging.LevelInfo)
+
+	assert.Same(t, logger, logging.FromContext(logging.NewContext(context.Background(), logger)))
+	assert.Same(t, logging.Default(), logging.FromContext(context.Background()))
+}
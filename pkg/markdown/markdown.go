@@ -0,0 +1,263 @@
+// Package markdown renders the Markdown subset reviewers may write:
+// paragraphs, line breaks, bulleted and numbered lists, *emphasis*,
+// **strong emphasis** and [links](https://example.com). Anything else,
+// raw HTML included, is shown as typed. The output is passed through
+// Sanitize, so only the tags this package produces can reach a page.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Renderer turns review Markdown into HTML.
+type Renderer struct {
+	// Text, if set, rewrites each run of plain text before it is escaped,
+	// such as to mask profanity. Markup is never passed to it.
+	Text func(string) string
+}
+
+var (
+	bulletItem  = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	orderedItem = regexp.MustCompile(`^\s{0,3}(\d{1,9})[.)]\s+(.*)$`)
+)
+
+// Render renders source as a sequence of blocks: paragraphs and lists.
+func (r *Renderer) Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	var out strings.Builder
+	var paragraph []string
+	var list *listBlock
+
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		out.WriteString("<p>")
+		out.WriteString(r.lines(paragraph))
+		out.WriteString("</p>\n")
+		paragraph = nil
+	}
+	flushList := func() {
+		if list == nil {
+			return
+		}
+		list.write(&out, r)
+		list = nil
+	}
+
+	for _, line := range strings.Split(source, "\n") {
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			flushList()
+			continue
+		}
+
+		if match := bulletItem.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			if list != nil && list.ordered {
+				flushList()
+			}
+			if list == nil {
+				list = &listBlock{}
+			}
+			list.items = append(list.items, []string{match[1]})
+			continue
+		}
+		if match := orderedItem.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			if list != nil && !list.ordered {
+				flushList()
+			}
+			if list == nil {
+				start, _ := strconv.Atoi(match[1])
+				list = &listBlock{ordered: true, start: start}
+			}
+			list.items = append(list.items, []string{match[2]})
+			continue
+		}
+
+		if list != nil {
+			// A line after an item without a marker carries on the item.
+			last := len(list.items) - 1
+			list.items[last] = append(list.items[last], line)
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flushParagraph()
+	flushList()
+
+	return Sanitize(strings.TrimSuffix(out.String(), "\n"))
+}
+
+// RenderInline renders the inline markup of source, without paragraphs or
+// lists, for a snippet shown within other text such as an excerpt.
+func (r *Renderer) RenderInline(source string) string {
+	return Sanitize(r.inline(source))
+}
+
+// lines renders the lines of a paragraph or list item, keeping the line
+// breaks the reviewer typed.
+func (r *Renderer) lines(lines []string) string {
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = r.inline(strings.TrimSpace(line))
+	}
+	return strings.Join(rendered, "<br>\n")
+}
+
+type listBlock struct {
+	ordered bool
+	start   int
+	items   [][]string
+}
+
+func (l *listBlock) write(out *strings.Builder, r *Renderer) {
+	switch {
+	case !l.ordered:
+		out.WriteString("<ul>\n")
+	case l.start != 1:
+		out.WriteString(`<ol start="` + strconv.Itoa(l.start) + `">` + "\n")
+	default:
+		out.WriteString("<ol>\n")
+	}
+	for _, item := range l.items {
+		out.WriteString("<li>")
+		out.WriteString(r.lines(item))
+		out.WriteString("</li>\n")
+	}
+	if l.ordered {
+		out.WriteString("</ol>\n")
+	} else {
+		out.WriteString("</ul>\n")
+	}
+}
+
+// inline renders emphasis, links and backslash escapes in one line.
+func (r *Renderer) inline(source string) string {
+	var out, text strings.Builder
+	flushText := func() {
+		if text.Len() == 0 {
+			return
+		}
+		run := text.String()
+		if r != nil && r.Text != nil {
+			run = r.Text(run)
+		}
+		out.WriteString(html.EscapeString(run))
+		text.Reset()
+	}
+
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '\\' && i+1 < len(source) && isPunctuation(source[i+1]):
+			text.WriteByte(source[i+1])
+			i += 2
+			continue
+
+		case c == '[':
+			if label, href, width, ok := parseLink(source[i:]); ok {
+				flushText()
+				if safeURL(href) {
+					out.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow ugc noopener">`)
+					out.WriteString(r.inline(label))
+					out.WriteString("</a>")
+				} else {
+					// The reader still sees what was written, just not as a
+					// link.
+					out.WriteString(r.inline(label))
+				}
+				i += width
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if inner, width, strong, ok := parseEmphasis(source, i); ok {
+				flushText()
+				tag := "em"
+				if strong {
+					tag = "strong"
+				}
+				out.WriteString("<" + tag + ">" + r.inline(inner) + "</" + tag + ">")
+				i += width
+				continue
+			}
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flushText()
+
+	return out.String()
+}
+
+// parseLink reads [label](href) at the start of s.
+func parseLink(s string) (label, href string, width int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel < 1 {
+		return "", "", 0, false
+	}
+	label = s[1:closeLabel]
+	if strings.ContainsAny(label, "[]") {
+		return "", "", 0, false
+	}
+	closeHref := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeHref < 0 {
+		return "", "", 0, false
+	}
+	href = strings.TrimSpace(s[closeLabel+2 : closeLabel+2+closeHref])
+	if href == "" || strings.ContainsAny(href, " \t") {
+		return "", "", 0, false
+	}
+	return label, href, closeLabel + 2 + closeHref + 1, true
+}
+
+// parseEmphasis reads *em*, _em_, **strong** or __strong__ starting at
+// source[i]. The text inside can't start or end with a space, and
+// underscores only count at word boundaries, so snake_case stays as it is.
+func parseEmphasis(source string, i int) (inner string, width int, strong bool, ok bool) {
+	delimiter := source[i : i+1]
+	if strings.HasPrefix(source[i:], delimiter+delimiter) {
+		delimiter += delimiter
+		strong = true
+	}
+	if delimiter[0] == '_' && i > 0 && isWordByte(source[i-1]) {
+		return "", 0, false, false
+	}
+
+	start := i + len(delimiter)
+	for end := start + 1; end <= len(source)-len(delimiter); end++ {
+		if source[end:end+len(delimiter)] != delimiter || source[end-1] == '\\' {
+			continue
+		}
+		// A single delimiter must not be half of a double one.
+		if !strong && end+1 < len(source) && source[end+1] == delimiter[0] {
+			end++
+			continue
+		}
+		after := end + len(delimiter)
+		if delimiter[0] == '_' && after < len(source) && isWordByte(source[after]) {
+			continue
+		}
+		inner = source[start:end]
+		if strings.TrimSpace(inner) != inner {
+			return "", 0, false, false
+		}
+		return inner, after - i, strong, true
+	}
+	return "", 0, false, false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isPunctuation(c byte) bool {
+	return strings.IndexByte("\\`*_{}[]()#+-.!<>", c) >= 0
+}
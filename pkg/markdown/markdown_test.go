@@ -0,0 +1,102 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/markdown"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"Plain", "Great pad thai", "<p>Great pad thai</p>"},
+		{"Paragraphs", "First visit.\n\nSecond visit.", "<p>First visit.</p>\n<p>Second visit.</p>"},
+		{"Line Breaks", "Noodles: great\r\nService: slow", "<p>Noodles: great<br>\nService: slow</p>"},
+		{"Emphasis", "*really* good, **very** spicy, _quite_ __cheap__", "<p><em>really</em> good, <strong>very</strong> spicy, <em>quite</em> <strong>cheap</strong></p>"},
+		{"Nested Emphasis", "**so *very* good**", "<p><strong>so <em>very</em> good</strong></p>"},
+		{"Unclosed Emphasis", "5 * 3 and **loose", "<p>5 * 3 and **loose</p>"},
+		{"Snake Case", "try som_tam_thai", "<p>try som_tam_thai</p>"},
+		{"Escapes", `\*not emphasis\*`, "<p>*not emphasis*</p>"},
+		{"Bullets", "Liked:\n- pad thai\n* som tam\n\nDone", "<p>Liked:</p>\n<ul>\n<li>pad thai</li>\n<li>som tam</li>\n</ul>\n<p>Done</p>"},
+		{"Numbered", "3. third\n4) fourth", "<ol start=\"3\">\n<li>third</li>\n<li>fourth</li>\n</ol>"},
+		{"Item Continues", "1. noodles\n   were soft", "<ol>\n<li>noodles<br>\nwere soft</li>\n</ol>"},
+		{"Link", "[menu](https://example.com/menu?a=1&b=2)", `<p><a href="https://example.com/menu?a=1&amp;b=2" rel="nofollow ugc noopener">menu</a></p>`},
+		{"Site Link", "see [review 2](/reviews/2)", `<p>see <a href="/reviews/2" rel="nofollow ugc noopener">review 2</a></p>`},
+		{"Link Text Emphasis", "[**menu**](http://example.com)", `<p><a href="http://example.com" rel="nofollow ugc noopener"><strong>menu</strong></a></p>`},
+		{"Thai", "**อร่อยมาก** ร้านนี้", "<p><strong>อร่อยมาก</strong> ร้านนี้</p>"},
+	}
+	renderer := &markdown.Renderer{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, renderer.Render(test.source))
+		})
+	}
+}
+
+func TestRenderUnsafe(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"Script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"Raw HTML", `<img src=x onerror="alert(1)">`, "<p>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>"},
+		{"JavaScript Link", "[click](javascript:alert(1))", "<p>click)</p>"},
+		{"Encoded Scheme", "[click](JaVaScRiPt:alert)", "<p>click</p>"},
+		{"Data Link", "[click](data:text/html;base64,PHNjcmlwdD4=)", "<p>click</p>"},
+		{"Scheme Relative", "[click](//evil.example)", "<p>click</p>"},
+		{"Quote In Href", `[click](https://example.com/"onmouseover="alert(1))`, `<p><a href="https://example.com/&#34;onmouseover=&#34;alert(1" rel="nofollow ugc noopener">click</a>)</p>`},
+		{"Markup In Label", "[<b>x</b>](https://example.com)", `<p><a href="https://example.com" rel="nofollow ugc noopener">&lt;b&gt;x&lt;/b&gt;</a></p>`},
+	}
+	renderer := &markdown.Renderer{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := renderer.Render(test.source)
+			assert.Equal(t, test.want, got)
+			assert.NotContains(t, strings.ToLower(got), "<script")
+			assert.NotContains(t, strings.ToLower(got), "javascript:")
+		})
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	renderer := &markdown.Renderer{Text: strings.ToUpper}
+	assert.Equal(t, `<p>SO <strong>GOOD</strong> <a href="https://example.com/a" rel="nofollow ugc noopener">MENU</a></p>`,
+		renderer.Render("so **good** [menu](https://example.com/a)"), "markup and links aren't rewritten")
+
+	masked := &markdown.Renderer{Text: func(s string) string { return strings.ReplaceAll(s, "darn", "d***") }}
+	assert.Equal(t, "<p><em>d***</em> good</p>", masked.Render("*darn* good"), "masked words don't turn into emphasis")
+}
+
+func TestRenderInline(t *testing.T) {
+	renderer := &markdown.Renderer{}
+	assert.Equal(t, "<strong>crispy</strong> pork &lt;br&gt;", renderer.RenderInline("**crispy** pork <br>"))
+	assert.Equal(t, "- not a list", renderer.RenderInline("- not a list"))
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{"Allowed", `<p><em>a</em><br><a href="/x" rel="nofollow">b</a></p>`, `<p><em>a</em><br><a href="/x" rel="nofollow">b</a></p>`},
+		{"Unknown Tags Dropped", `<div><script>alert(1)</script></div>`, `alert(1)`},
+		{"Unknown Attributes Dropped", `<p onclick="alert(1)" style="x">a</p>`, `<p>a</p>`},
+		{"Unsafe Href Dropped", `<a href="javascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"Entity Encoded Href Dropped", `<a href="&#106;avascript:alert(1)">a</a>`, `<a>a</a>`},
+		{"List Start", `<ol start="2"></ol><ol start="x"></ol>`, `<ol start="2"></ol><ol></ol>`},
+		{"Stray Brackets", `a < b > c`, `a &lt; b &gt; c`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, markdown.Sanitize(test.fragment))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowedTags lists the tags that may appear in rendered reviews and the
+// attributes each may carry. It matches what Render produces, so Sanitize
+// only removes something if the renderer has a bug.
+var allowedTags = map[string]map[string]bool{
+	"p":      {},
+	"br":     {},
+	"em":     {},
+	"strong": {},
+	"ul":     {},
+	"ol":     {"start": true},
+	"li":     {},
+	"a":      {"href": true, "rel": true},
+}
+
+// allowedSchemes are the link targets reviews may point to. Relative links
+// stay on the site.
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+var (
+	tagPattern       = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[a-zA-Z-]+="[^"<>]*")*)\s*(/?)>`)
+	attributePattern = regexp.MustCompile(`([a-zA-Z-]+)="([^"]*)"`)
+	digits           = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Sanitize keeps the tags and attributes on the allow-list in fragment and
+// drops every other tag. Text is expected to be escaped already, so a stray
+// "<" or ">" that isn't part of a tag is escaped.
+func Sanitize(fragment string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range tagPattern.FindAllStringSubmatchIndex(fragment, -1) {
+		out.WriteString(escapeBrackets(fragment[last:loc[0]]))
+		last = loc[1]
+
+		closing := fragment[loc[2]:loc[3]] == "/"
+		name := strings.ToLower(fragment[loc[4]:loc[5]])
+		attributes, ok := allowedTags[name]
+		if !ok {
+			continue
+		}
+		if closing {
+			if name != "br" {
+				out.WriteString("</" + name + ">")
+			}
+			continue
+		}
+
+		out.WriteString("<" + name)
+		for _, attribute := range attributePattern.FindAllStringSubmatch(fragment[loc[6]:loc[7]], -1) {
+			key, value := strings.ToLower(attribute[1]), html.UnescapeString(attribute[2])
+			if !attributes[key] || !safeAttribute(key, value) {
+				continue
+			}
+			out.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+		}
+		out.WriteString(">")
+	}
+	out.WriteString(escapeBrackets(fragment[last:]))
+
+	return out.String()
+}
+
+func safeAttribute(key, value string) bool {
+	switch key {
+	case "href":
+		return safeURL(value)
+	case "start":
+		return digits.MatchString(value)
+	}
+	return true
+}
+
+// safeURL accepts absolute links with an allowed scheme and links within
+// the site. Scheme-relative links ("//host") would leave the site without
+// saying how, so they're refused.
+func safeURL(href string) bool {
+	if strings.HasPrefix(href, "/") {
+		return !strings.HasPrefix(href, "//") && !strings.HasPrefix(href, `/\`)
+	}
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	return allowedSchemes[strings.ToLower(parsed.Scheme)]
+}
+
+func escapeBrackets(text string) string {
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(text)
+}
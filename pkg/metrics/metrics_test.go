@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/metrics"
+)
+
+func TestCounterVec(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := registry.NewCounterVec("requests_total", "Requests served.", "route", "code")
+
+	requests.Inc("/reviews/{reviewID}", "200")
+	requests.Inc("/reviews", "200")
+	requests.Inc("/reviews/{reviewID}", "200")
+	requests.Add(2, "say \"hi\"\n", "500")
+
+	var out bytes.Buffer
+	_, err := registry.WriteTo(&out)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/reviews",code="200"} 1
+requests_total{route="/reviews/{reviewID}",code="200"} 2
+requests_total{route="say \"hi\"\n",code="500"} 2
+`, out.String())
+	}
+
+	assert.Panics(t, func() { requests.Inc("/reviews") })
+}
+
+func TestHistogramVec(t *testing.T) {
+	registry := metrics.NewRegistry()
+	duration := registry.NewHistogramVec("duration_seconds", "Time taken.", []float64{0.1, 1}, "operation")
+
+	duration.Observe(0.05, "Search")
+	duration.Observe(0.1, "Search")
+	duration.Observe(0.5, "Search")
+	duration.Observe(3, "Search")
+
+	var out bytes.Buffer
+	_, err := registry.WriteTo(&out)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `# HELP duration_seconds Time taken.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{operation="Search",le="0.1"} 2
+duration_seconds_bucket{operation="Search",le="1"} 3
+duration_seconds_bucket{operation="Search",le="+Inf"} 4
+duration_seconds_sum{operation="Search"} 3.65
+duration_seconds_count{operation="Search"} 4
+`, out.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounterVec("up", "Always one.").Inc()
+
+	w := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, w.Body.String(), "\nup 1\n")
+}
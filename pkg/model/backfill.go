@@ -0,0 +1,178 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+
+	"food-review/pkg/fingerprint"
+	"food-review/pkg/sentiment"
+)
+
+const (
+	// BackfillFingerprint hashes the reviews written before 0005_fingerprint.
+	BackfillFingerprint = "fingerprint"
+	// BackfillKeywordMention counts the dictionary keywords in the reviews
+	// written before 0004_stats.
+	BackfillKeywordMention = "keyword_mention"
+	// BackfillSentiment scores the reviews written before 0003_sentiment.
+	BackfillSentiment = "sentiment"
+)
+
+// backfills fill in what migrations can't compute in SQL.
+var backfills = map[string]func(tx *sql.Tx, keywords []string) error{
+	BackfillFingerprint:    backfillFingerprints,
+	BackfillKeywordMention: backfillKeywordMentions,
+	BackfillSentiment:      backfillSentiments,
+}
+
+// RunBackfills runs the backfills migrations queued in pending_backfill and
+// returns their names. keywords are the dictionary's. Each backfill runs in
+// the transaction that takes it off the queue, so one that is interrupted
+// runs again on the next start.
+func RunBackfills(db *sql.DB, keywords []string) ([]string, error) {
+	var pending []string
+	rows, err := db.Query("SELECT name FROM pending_backfill ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pending = append(pending, name)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []string
+	for _, name := range pending {
+		backfill, ok := backfills[name]
+		if !ok {
+			return ran, fmt.Errorf("unknown backfill %q", name)
+		}
+		if err := runBackfill(db, name, backfill, keywords); err != nil {
+			return ran, fmt.Errorf("backfill %s: %w", name, err)
+		}
+		ran = append(ran, name)
+	}
+
+	return ran, nil
+}
+
+func runBackfill(db *sql.DB, name string, backfill func(tx *sql.Tx, keywords []string) error, keywords []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := backfill(tx, keywords); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM pending_backfill WHERE name = $1", name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type reviewContent struct {
+	reviewID uint
+	content  string
+}
+
+// readContents reads every review statement selects, as ID and content,
+// before the caller writes. Postgres can't run a statement on a connection
+// with rows still open.
+func readContents(tx *sql.Tx, statement string) ([]reviewContent, error) {
+	var found []reviewContent
+
+	rows, err := tx.Query(statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var review reviewContent
+		if err := rows.Scan(&review.reviewID, &review.content); err != nil {
+			return nil, err
+		}
+		found = append(found, review)
+	}
+
+	return found, rows.Err()
+}
+
+// backfillFingerprints hashes every review whose content_hash is still
+// empty.
+func backfillFingerprints(tx *sql.Tx, _ []string) error {
+	found, err := readContents(tx, "SELECT review_id, COALESCE(review, '') FROM review WHERE content_hash = ''")
+	if err != nil {
+		return err
+	}
+
+	for _, review := range found {
+		_, err := tx.Exec("UPDATE review SET content_hash = $1, simhash = $2 WHERE review_id = $3",
+			fingerprint.ContentHash(review.content), int64(fingerprint.SimHash(review.content)), review.reviewID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillKeywordMentions recounts every keyword in every review, as
+// RefreshKeywordMentions does for one review.
+func backfillKeywordMentions(tx *sql.Tx, keywords []string) error {
+	found, err := readContents(tx, "SELECT review_id, COALESCE(review, '') FROM review")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM keyword_mention"); err != nil {
+		return err
+	}
+	for _, review := range found {
+		for _, keyword := range keywords {
+			mentions := CountMentions(review.content, keyword)
+			if mentions == 0 {
+				continue
+			}
+			_, err := tx.Exec("INSERT INTO keyword_mention (review_id, keyword, mentions) VALUES ($1, $2, $3)",
+				review.reviewID, keyword, mentions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// backfillSentiments scores the reviews still at the neutral 0 that
+// 0003_sentiment gave them. Reviews scored since then at neutral 0 come out
+// the same.
+func backfillSentiments(tx *sql.Tx, _ []string) error {
+	found, err := readContents(tx, "SELECT review_id, COALESCE(review, '') FROM review WHERE sentiment = 'neutral' AND sentiment_score = 0")
+	if err != nil {
+		return err
+	}
+
+	for _, review := range found {
+		result := sentiment.Score(review.content)
+		_, err := tx.Exec("UPDATE review SET sentiment = $1, sentiment_score = $2 WHERE review_id = $3",
+			result.Label, result.Score, review.reviewID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
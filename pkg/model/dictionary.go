@@ -0,0 +1,110 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"food-review/pkg/extract"
+	"food-review/pkg/sentiment"
+)
+
+// keywordCandidateLimit caps how many candidates the admin page lists.
+const keywordCandidateLimit = 50
+
+var (
+	ErrEmptyKeyword  = errors.New("keyword is empty")
+	ErrKeywordExists = errors.New("keyword already in dictionary")
+)
+
+type keywordEntry struct {
+	Keyword string `json:"keyword"`
+}
+
+func GetAllKeywords(db *sql.DB) ([]string, error) {
+	var keywords []string
+
+	statement := "SELECT keyword FROM dictionary ORDER BY keyword"
+	rows, err := db.Query(statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyword string
+		if err := rows.Scan(&keyword); err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, keyword)
+	}
+
+	return keywords, rows.Err()
+}
+
+// ParseKeyword reads a keyword to add, lowercased with its spaces collapsed.
+func ParseKeyword(keywordBody []byte) (string, error) {
+	entry := keywordEntry{}
+
+	err := json.Unmarshal(keywordBody, &entry)
+	if err != nil {
+		return "", err
+	}
+	keyword := strings.ToLower(strings.Join(strings.Fields(entry.Keyword), " "))
+	if keyword == "" {
+		return "", ErrEmptyKeyword
+	}
+
+	return keyword, nil
+}
+
+func AddKeyword(db *sql.DB, keywordBody []byte) (string, error) {
+	keyword, err := ParseKeyword(keywordBody)
+	if err != nil {
+		return "", err
+	}
+
+	exist, err := KeywordExists(db, keyword)
+	if err != nil {
+		return "", err
+	} else if exist {
+		return "", ErrKeywordExists
+	}
+
+	insertStatement := "INSERT INTO dictionary (keyword) VALUES ($1)"
+	_, err = db.Exec(insertStatement, keyword)
+	if err != nil {
+		return "", err
+	}
+
+	return keyword, nil
+}
+
+// ScanKeywordCandidates scans approved reviews for food terms that are not in
+// the dictionary yet. Sentiment words are treated as stopwords so "delicious"
+// is never suggested as a dish.
+func ScanKeywordCandidates(db *sql.DB, existing []string) ([]extract.Candidate, error) {
+	reviews, err := GetAllReviews(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return ExtractKeywordCandidates(reviews, existing), nil
+}
+
+// ExtractKeywordCandidates ranks the terms of reviews that could be added to
+// the dictionary.
+func ExtractKeywordCandidates(reviews []*Review, existing []string) []extract.Candidate {
+	docs := make([]string, 0, len(reviews))
+	for _, review := range reviews {
+		docs = append(docs, review.Content)
+	}
+
+	extractor := extract.NewExtractor()
+	for _, word := range sentiment.Words() {
+		extractor.Stopwords[word] = true
+	}
+
+	return extractor.Extract(docs, existing, keywordCandidateLimit)
+}
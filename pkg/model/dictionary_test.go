@@ -0,0 +1,75 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/model"
+)
+
+func TestAddKeyword(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	existStatement := "SELECT keyword FROM dictionary WHERE keyword = $1"
+	insertStatement := "INSERT INTO dictionary (keyword) VALUES ($1)"
+
+	t.Run("Empty Keyword", func(t *testing.T) {
+		_, err := model.AddKeyword(db, []byte(`{"keyword": "  "}`))
+		assert.ErrorIs(t, err, model.ErrEmptyKeyword)
+	})
+
+	t.Run("Already in Dictionary", func(t *testing.T) {
+		mock.ExpectQuery(existStatement).
+			WithArgs("khao soi").
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("khao soi"))
+
+		_, err := model.AddKeyword(db, []byte(`{"keyword": "khao soi"}`))
+		assert.ErrorIs(t, err, model.ErrKeywordExists)
+	})
+
+	t.Run("Happy Path Normalizes Keyword", func(t *testing.T) {
+		mock.ExpectQuery(existStatement).
+			WithArgs("mango sticky rice").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(insertStatement).
+			WithArgs("mango sticky rice").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		keyword, err := model.AddKeyword(db, []byte(`{"keyword": " Mango  Sticky Rice "}`))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "mango sticky rice", keyword)
+		}
+	})
+}
+
+func TestScanKeywordCandidates(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 ORDER BY review_id"
+
+	t.Run("Sentiment Words Are Not Candidates", func(t *testing.T) {
+		mock.ExpectQuery(statement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow(1, "Delicious khao soi", model.StatusApproved, "positive", 0.6, reviewedAt, reviewedAt, 0).
+				AddRow(2, "Delicious khao soi and tasty larb", model.StatusApproved, "positive", 0.7, reviewedAt, reviewedAt, 0).
+				AddRow(3, "Tasty larb", model.StatusApproved, "positive", 0.5, reviewedAt, reviewedAt, 0))
+
+		candidates, err := model.ScanKeywordCandidates(db, []string{"larb"})
+		if assert.NoError(t, err) && assert.NotEmpty(t, candidates) {
+			assert.Equal(t, "khao soi", candidates[0].Term)
+			for _, c := range candidates {
+				assert.NotContains(t, c.Term, "delicious")
+				assert.NotEqual(t, "larb", c.Term)
+			}
+		}
+	})
+}
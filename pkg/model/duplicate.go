@@ -0,0 +1,247 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"food-review/pkg/fingerprint"
+)
+
+// FlagDuplicate is raised by the system rather than by readers, so it is not
+// one of the FlagReasons accepted from FlagReview.
+const FlagDuplicate = "duplicate"
+
+const ActionMerged = "merged"
+
+var (
+	ErrDuplicateReview = errors.New("an identical review already exists")
+	ErrMergeTooFew     = errors.New("merge needs at least two review IDs")
+)
+
+type DuplicateCluster struct {
+	Reviews []*Review
+}
+
+type mergeRequest struct {
+	ReviewIDs []uint `json:"review_ids"`
+}
+
+type reviewFingerprint struct {
+	reviewID uint
+	simHash  uint64
+}
+
+// findExactDuplicate returns the ID of another review with the same
+// normalised content, or 0 when there is none.
+func findExactDuplicate(db querier, contentHash string, excludeID uint) (uint, error) {
+	var duplicateID uint
+
+	statement := "SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1"
+	err := db.QueryRow(statement, contentHash, excludeID).Scan(&duplicateID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return duplicateID, nil
+}
+
+func getFingerprints(db querier) ([]reviewFingerprint, error) {
+	var fingerprints []reviewFingerprint
+
+	statement := "SELECT review_id, simhash FROM review WHERE content_hash != '' ORDER BY review_id"
+	err := queryEach(db, func(rows *sql.Rows) error {
+		var fp reviewFingerprint
+		var simHash int64
+		err := rows.Scan(&fp.reviewID, &simHash)
+		fp.simHash = uint64(simHash)
+		fingerprints = append(fingerprints, fp)
+		return err
+	}, statement)
+
+	return fingerprints, err
+}
+
+// flagNearDuplicates raises a duplicate flag on a review whose SimHash is
+// within fingerprint.NearDuplicateDistance of any other review.
+func flagNearDuplicates(tx *sql.Tx, reviewID uint, simHash uint64) error {
+	fingerprints, err := getFingerprints(tx)
+	if err != nil {
+		return err
+	}
+
+	var similar []uint
+	for _, fp := range fingerprints {
+		if fp.reviewID != reviewID && fingerprint.NearDuplicate(fp.simHash, simHash) {
+			similar = append(similar, fp.reviewID)
+		}
+	}
+	if len(similar) == 0 {
+		return nil
+	}
+
+	return insertFlag(tx, reviewID, FlagDuplicate, SimilarNote(similar))
+}
+
+// SimilarNote is the note on a duplicate flag listing the similar reviews.
+func SimilarNote(similar []uint) string {
+	return "similar to " + FormatIDs(similar)
+}
+
+// FormatIDs lists review IDs for notes, e.g. "#2, #5".
+func FormatIDs(reviewIDs []uint) string {
+	formatted := make([]string, len(reviewIDs))
+	for i, reviewID := range reviewIDs {
+		formatted[i] = fmt.Sprintf("#%d", reviewID)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// GetDuplicateClusters groups reviews whose fingerprints are near-duplicates
+// of each other, directly or through a chain of near-duplicates.
+func GetDuplicateClusters(db *sql.DB) ([]*DuplicateCluster, error) {
+	fingerprints, err := getFingerprints(db)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewIDs := make([]uint, len(fingerprints))
+	simHashes := make([]uint64, len(fingerprints))
+	for i, fp := range fingerprints {
+		reviewIDs[i] = fp.reviewID
+		simHashes[i] = fp.simHash
+	}
+
+	var clusters []*DuplicateCluster
+	for _, members := range ClusterFingerprints(reviewIDs, simHashes) {
+		cluster := &DuplicateCluster{}
+		for _, reviewID := range members {
+			review, err := GetReview(db, reviewID)
+			if err != nil {
+				return nil, err
+			}
+			cluster.Reviews = append(cluster.Reviews, review)
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
+// ClusterFingerprints groups review IDs whose SimHashes are near-duplicates of
+// each other, directly or through a chain of near-duplicates. Only groups of
+// two or more are returned, ordered by their lowest ID.
+func ClusterFingerprints(reviewIDs []uint, simHashes []uint64) [][]uint {
+	parent := make([]int, len(reviewIDs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range simHashes {
+		for j := i + 1; j < len(simHashes); j++ {
+			if fingerprint.NearDuplicate(simHashes[i], simHashes[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := map[int][]uint{}
+	for i, reviewID := range reviewIDs {
+		root := find(i)
+		members[root] = append(members[root], reviewID)
+	}
+
+	var clusters [][]uint
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i] < group[j] })
+		clusters = append(clusters, group)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i][0] < clusters[j][0]
+	})
+
+	return clusters
+}
+
+// MergeReviews keeps the oldest (lowest) of the given review IDs and deletes
+// the rest along with their flags, audit entries and keyword mentions. The
+// merge is audited as moderator's.
+func MergeReviews(db *sql.DB, moderator string, mergeBody []byte) (uint, error) {
+	reviewIDs, err := ParseMergeRequest(mergeBody)
+	if err != nil {
+		return 0, err
+	}
+	keepID, removeIDs := reviewIDs[0], reviewIDs[1:]
+
+	for _, reviewID := range reviewIDs {
+		if _, err := GetReview(db, reviewID); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, removeID := range removeIDs {
+		err = deleteReview(tx, removeID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = writeAudit(tx, keepID, moderator, ActionMerged, "merged "+FormatIDs(removeIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	return keepID, tx.Commit()
+}
+
+// ParseMergeRequest reads the review IDs to merge, deduplicated and sorted so
+// the review to keep comes first.
+func ParseMergeRequest(mergeBody []byte) ([]uint, error) {
+	request := mergeRequest{}
+
+	err := json.Unmarshal(mergeBody, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewIDs := uniqueIDs(request.ReviewIDs)
+	if len(reviewIDs) < 2 {
+		return nil, ErrMergeTooFew
+	}
+
+	return reviewIDs, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	var unique []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique
+}
@@ -0,0 +1,129 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/fingerprint"
+	"food-review/pkg/model"
+)
+
+const fingerprintStatement = "SELECT review_id, simhash FROM review WHERE content_hash != '' ORDER BY review_id"
+
+func TestCreateReviewNearDuplicate(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	original := "The khao soi here is rich and creamy with perfectly tender chicken, crispy noodles on top and a squeeze of lime."
+	edited := original + " Loved it"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("INSERT INTO review (review, status, sentiment, sentiment_score, content_hash, simhash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING review_id").
+		WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(8))
+	mock.ExpectQuery(fingerprintStatement).
+		WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}).
+			AddRow(2, int64(fingerprint.SimHash(original))).
+			AddRow(8, int64(fingerprint.SimHash(edited))))
+	mock.ExpectExec("INSERT INTO review_flag (review_id, reason, note) VALUES ($1, $2, $3)").
+		WithArgs(uint(8), model.FlagDuplicate, "similar to #2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(auditStatement).
+		WithArgs(uint(8), model.ActionFlagged, "duplicate: similar to #2", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	review, err := model.CreateReview(db, []byte(`{"review": "`+edited+`"}`), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(8), review.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestGetDuplicateClusters(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}).
+				AddRow(1, int64(0b0000)).
+				AddRow(2, int64(0xffff)).
+				AddRow(3, int64(0b0111)).
+				AddRow(4, int64(0b1_0111)))
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(1)).
+			WillReturnRows(reviewRow(1, "Original", model.StatusApproved))
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(3)).
+			WillReturnRows(reviewRow(3, "Original!", model.StatusPending))
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(4)).
+			WillReturnRows(reviewRow(4, "Original!!", model.StatusPending))
+
+		clusters, err := model.GetDuplicateClusters(db)
+		if assert.NoError(t, err) && assert.Len(t, clusters, 1) {
+			assert.Len(t, clusters[0].Reviews, 3)
+			assert.Equal(t, uint(1), clusters[0].Reviews[0].ID)
+		}
+	})
+}
+
+func TestMergeReviews(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	t.Run("Too Few IDs", func(t *testing.T) {
+		_, err := model.MergeReviews(db, "anna", []byte(`{"review_ids": [3, 3]}`))
+		assert.ErrorIs(t, err, model.ErrMergeTooFew)
+	})
+
+	t.Run("Missing Review", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(3)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := model.MergeReviews(db, "anna", []byte(`{"review_ids": [7, 3]}`))
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Keeps Older ID", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(3)).
+			WillReturnRows(reviewRow(3, "Original", model.StatusApproved))
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(7)).
+			WillReturnRows(reviewRow(7, "Original!", model.StatusPending))
+		mock.ExpectBegin()
+		for _, statement := range []string{
+			"DELETE FROM keyword_mention WHERE review_id = $1",
+			"DELETE FROM review_flag WHERE review_id = $1",
+			"DELETE FROM review_audit WHERE review_id = $1",
+			"DELETE FROM review WHERE review_id = $1",
+		} {
+			mock.ExpectExec(statement).
+				WithArgs(uint(7)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec(auditStatement).
+			WithArgs(uint(3), model.ActionMerged, "merged #7", "anna").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		keptID, err := model.MergeReviews(db, "anna", []byte(`{"review_ids": [7, 3]}`))
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint(3), keptID)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
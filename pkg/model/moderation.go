@@ -0,0 +1,297 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+const (
+	ActionFlagged   = "flagged"
+	ActionApproved  = "approved"
+	ActionRejected  = "rejected"
+	ActionAnnotated = "annotated"
+)
+
+var ErrInvalidFlagReason = errors.New("flag reason must be one of spam, offensive or off-topic")
+
+var FlagReasons = []string{"spam", "offensive", "off-topic"}
+
+type Flag struct {
+	ID       uint   `json:"flag_id"`
+	ReviewID uint   `json:"review_id"`
+	Reason   string `json:"reason"`
+	Note     string `json:"note"`
+}
+
+type AuditEntry struct {
+	ID       uint
+	ReviewID uint
+	Action   string
+	Note     string
+	// Moderator is who took the action, or "" for flags raised by readers
+	// and the system.
+	Moderator string
+	CreatedAt time.Time
+}
+
+type QueueItem struct {
+	Review *Review
+	Flags  []*Flag
+	Audit  []*AuditEntry
+}
+
+type moderationNote struct {
+	Note string `json:"note"`
+}
+
+func validFlagReason(reason string) bool {
+	for _, r := range FlagReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFlag reads a reader's flag, which must give one of FlagReasons.
+func ParseFlag(flagBody []byte) (*Flag, error) {
+	flag := Flag{}
+
+	err := json.Unmarshal(flagBody, &flag)
+	if err != nil {
+		return nil, err
+	}
+	if !validFlagReason(flag.Reason) {
+		return nil, ErrInvalidFlagReason
+	}
+
+	return &flag, nil
+}
+
+// FlagAuditNote is the audit note recorded when a flag is raised.
+func FlagAuditNote(reason string, note string) string {
+	if note == "" {
+		return reason
+	}
+	return reason + ": " + note
+}
+
+func FlagReview(db *sql.DB, reviewID uint, flagBody []byte) error {
+	flag, err := ParseFlag(flagBody)
+	if err != nil {
+		return err
+	}
+
+	if _, err := GetReview(db, reviewID); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	flagStatement := "INSERT INTO review_flag (review_id, reason, note) VALUES ($1, $2, $3)"
+	_, err = tx.Exec(flagStatement, reviewID, flag.Reason, flag.Note)
+	if err != nil {
+		return err
+	}
+
+	err = writeAudit(tx, reviewID, "", ActionFlagged, FlagAuditNote(flag.Reason, flag.Note))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func ApproveReview(db *sql.DB, reviewID uint, moderator string, noteBody []byte) error {
+	return moderateReview(db, reviewID, moderator, StatusApproved, ActionApproved, noteBody)
+}
+
+func RejectReview(db *sql.DB, reviewID uint, moderator string, noteBody []byte) error {
+	return moderateReview(db, reviewID, moderator, StatusRejected, ActionRejected, noteBody)
+}
+
+func AnnotateReview(db *sql.DB, reviewID uint, moderator string, noteBody []byte) error {
+	return moderateReview(db, reviewID, moderator, "", ActionAnnotated, noteBody)
+}
+
+// ParseModerationNote reads the optional note a moderator sends with an
+// action. An empty body means no note.
+func ParseModerationNote(noteBody []byte) (string, error) {
+	note := moderationNote{}
+	if len(noteBody) > 0 {
+		err := json.Unmarshal(noteBody, &note)
+		if err != nil {
+			return "", err
+		}
+	}
+	return note.Note, nil
+}
+
+// moderateReview records a moderator's action in the audit trail. A
+// non-empty status also moves the review to that status and resolves its open
+// flags.
+func moderateReview(db *sql.DB, reviewID uint, moderator string, status string, action string, noteBody []byte) error {
+	note, err := ParseModerationNote(noteBody)
+	if err != nil {
+		return err
+	}
+
+	if _, err := GetReview(db, reviewID); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if status != "" {
+		statusStatement := "UPDATE review SET status = $1 WHERE review_id = $2"
+		_, err = tx.Exec(statusStatement, status, reviewID)
+		if err != nil {
+			return err
+		}
+
+		resolveStatement := "UPDATE review_flag SET resolved = TRUE WHERE review_id = $1"
+		_, err = tx.Exec(resolveStatement, reviewID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeAudit(tx, reviewID, moderator, action, note)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertFlag records a flag the system raised on its own, along with its
+// audit entry, inside the caller's transaction.
+func insertFlag(tx *sql.Tx, reviewID uint, reason string, note string) error {
+	flagStatement := "INSERT INTO review_flag (review_id, reason, note) VALUES ($1, $2, $3)"
+	_, err := tx.Exec(flagStatement, reviewID, reason, note)
+	if err != nil {
+		return err
+	}
+
+	return writeAudit(tx, reviewID, "", ActionFlagged, FlagAuditNote(reason, note))
+}
+
+// insertFlags raises each of flags on the review inside the caller's
+// transaction.
+func insertFlags(tx *sql.Tx, reviewID uint, flags []Flag) error {
+	for _, flag := range flags {
+		err := insertFlag(tx, reviewID, flag.Reason, flag.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAudit records an action in the audit trail. moderator is "" for flags
+// raised by readers and the system.
+func writeAudit(tx *sql.Tx, reviewID uint, moderator string, action string, note string) error {
+	auditStatement := "INSERT INTO review_audit (review_id, action, note, moderator) VALUES ($1, $2, $3, $4)"
+	_, err := tx.Exec(auditStatement, reviewID, action, note, moderator)
+	return err
+}
+
+func GetModerationQueue(db *sql.DB) ([]*QueueItem, error) {
+	var queue []*QueueItem
+
+	statement := "SELECT " + reviewColumns + ` FROM review
+		WHERE status = $1
+		OR review_id IN (SELECT review_id FROM review_flag WHERE NOT resolved)
+		ORDER BY review_id`
+	rows, err := db.Query(statement, StatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		review := Review{}
+		err := scanReview(rows, &review)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, &QueueItem{Review: &review})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range queue {
+		item.Flags, err = GetOpenFlags(db, item.Review.ID)
+		if err != nil {
+			return nil, err
+		}
+		item.Audit, err = GetAuditTrail(db, item.Review.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return queue, nil
+}
+
+func GetOpenFlags(db *sql.DB, reviewID uint) ([]*Flag, error) {
+	var flags []*Flag
+
+	statement := "SELECT flag_id, review_id, reason, note FROM review_flag WHERE review_id = $1 AND NOT resolved ORDER BY flag_id"
+	rows, err := db.Query(statement, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		flag := Flag{}
+		err := rows.Scan(&flag.ID, &flag.ReviewID, &flag.Reason, &flag.Note)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, &flag)
+	}
+
+	return flags, rows.Err()
+}
+
+func GetAuditTrail(db *sql.DB, reviewID uint) ([]*AuditEntry, error) {
+	var trail []*AuditEntry
+
+	statement := "SELECT audit_id, review_id, action, note, moderator, created_at FROM review_audit WHERE review_id = $1 ORDER BY audit_id"
+	rows, err := db.Query(statement, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := AuditEntry{}
+		err := rows.Scan(&entry.ID, &entry.ReviewID, &entry.Action, &entry.Note, &entry.Moderator, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		trail = append(trail, &entry)
+	}
+
+	return trail, rows.Err()
+}
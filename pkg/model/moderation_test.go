@@ -0,0 +1,155 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/model"
+)
+
+const (
+	getReviewStatement = "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1"
+	auditStatement     = "INSERT INTO review_audit (review_id, action, note, moderator) VALUES ($1, $2, $3, $4)"
+)
+
+func reviewRow(id uint, content string, status string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+		AddRow(id, content, status, "neutral", 0.0, reviewedAt, reviewedAt, 0)
+}
+
+func TestFlagReview(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	flagStatement := "INSERT INTO review_flag (review_id, reason, note) VALUES ($1, $2, $3)"
+
+	t.Run("Invalid Reason", func(t *testing.T) {
+		err := model.FlagReview(db, 1, []byte(`{"reason": "boring"}`))
+		assert.ErrorIs(t, err, model.ErrInvalidFlagReason)
+	})
+
+	t.Run("No Review Found", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(9)).
+			WillReturnError(sql.ErrNoRows)
+
+		err := model.FlagReview(db, 9, []byte(`{"reason": "spam"}`))
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(1)).
+			WillReturnRows(reviewRow(1, "Buy cheap watches", model.StatusApproved))
+		mock.ExpectBegin()
+		mock.ExpectExec(flagStatement).
+			WithArgs(uint(1), "spam", "links everywhere").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(auditStatement).
+			WithArgs(uint(1), model.ActionFlagged, "spam: links everywhere", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := model.FlagReview(db, 1, []byte(`{"reason": "spam", "note": "links everywhere"}`))
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestModerateReview(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	statusStatement := "UPDATE review SET status = $1 WHERE review_id = $2"
+	resolveStatement := "UPDATE review_flag SET resolved = TRUE WHERE review_id = $1"
+
+	t.Run("Invalid Note", func(t *testing.T) {
+		err := model.ApproveReview(db, 1, "anna", []byte(`{"note": false}`))
+		assert.ErrorContains(t, err, "cannot unmarshal bool")
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(2)).
+			WillReturnRows(reviewRow(2, "Great khao soi", model.StatusPending))
+		mock.ExpectBegin()
+		mock.ExpectExec(statusStatement).
+			WithArgs(model.StatusApproved, uint(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(resolveStatement).
+			WithArgs(uint(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(auditStatement).
+			WithArgs(uint(2), model.ActionApproved, "looks fine", "anna").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := model.ApproveReview(db, 2, "anna", []byte(`{"note": "looks fine"}`))
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("Annotate Keeps Status", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(2)).
+			WillReturnRows(reviewRow(2, "Great khao soi", model.StatusPending))
+		mock.ExpectBegin()
+		mock.ExpectExec(auditStatement).
+			WithArgs(uint(2), model.ActionAnnotated, "asked author for details", "anna").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := model.AnnotateReview(db, 2, "anna", []byte(`{"note": "asked author for details"}`))
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestGetModerationQueue(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	queueStatement := `SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review
+		WHERE status = $1
+		OR review_id IN (SELECT review_id FROM review_flag WHERE NOT resolved)
+		ORDER BY review_id`
+	flagStatement := "SELECT flag_id, review_id, reason, note FROM review_flag WHERE review_id = $1 AND NOT resolved ORDER BY flag_id"
+	trailStatement := "SELECT audit_id, review_id, action, note, moderator, created_at FROM review_audit WHERE review_id = $1 ORDER BY audit_id"
+
+	t.Run("Happy Path", func(t *testing.T) {
+		flaggedAt := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(queueStatement).
+			WithArgs(model.StatusPending).
+			WillReturnRows(reviewRow(4, "Worst pad thai ever", model.StatusApproved))
+		mock.ExpectQuery(flagStatement).
+			WithArgs(uint(4)).
+			WillReturnRows(sqlmock.NewRows([]string{"flag_id", "review_id", "reason", "note"}).
+				AddRow(1, 4, "offensive", ""))
+		mock.ExpectQuery(trailStatement).
+			WithArgs(uint(4)).
+			WillReturnRows(sqlmock.NewRows([]string{"audit_id", "review_id", "action", "note", "moderator", "created_at"}).
+				AddRow(1, 4, model.ActionFlagged, "offensive", "", flaggedAt))
+
+		queue, err := model.GetModerationQueue(db)
+		if assert.NoError(t, err) && assert.Len(t, queue, 1) {
+			assert.Equal(t, uint(4), queue[0].Review.ID)
+			assert.Len(t, queue[0].Flags, 1)
+			assert.Equal(t, "offensive", queue[0].Flags[0].Reason)
+			assert.Equal(t, flaggedAt, queue[0].Audit[0].CreatedAt)
+		}
+	})
+}
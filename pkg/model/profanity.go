@@ -0,0 +1,22 @@
+package model
+
+import "strings"
+
+// FlagOffensive is the reason given both by readers and by the profanity
+// filter.
+const FlagOffensive = "offensive"
+
+// ProfanityFlags are the flags that put a review the profanity filter caught
+// in front of a moderator, noting the words it found. There are none for a
+// clean review.
+func ProfanityFlags(words []string) []Flag {
+	if len(words) == 0 {
+		return nil
+	}
+	return []Flag{{Reason: FlagOffensive, Note: ProfanityNote(words)}}
+}
+
+// ProfanityNote is the note on an offensive flag raised by the filter.
+func ProfanityNote(words []string) string {
+	return "profanity: " + strings.Join(words, ", ")
+}
@@ -3,30 +3,91 @@ package model
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"food-review/pkg/fingerprint"
+	"food-review/pkg/sentiment"
+)
+
+var (
+	ErrEmptyReview      = errors.New("review content is empty")
+	ErrInvalidSentiment = errors.New("sentiment must be one of positive, negative or neutral")
 )
 
+// reviewColumns is the column list every review query selects, in the order
+// scanReview expects.
+const reviewColumns = "review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count"
+
 type Review struct {
-	ID      uint   `json:"review_id"`
-	Content string `json:"review"`
-	Keyword string
+	ID             uint    `json:"review_id"`
+	Content        string  `json:"review"`
+	Status         string  `json:"status"`
+	Sentiment      string  `json:"sentiment"`
+	SentimentScore float64 `json:"sentiment_score"`
+	// CreatedAt and UpdatedAt are in UTC, to the second. UpdatedAt moves,
+	// and EditedCount goes up, when the content is edited, not when a
+	// moderator acts on the review.
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	EditedCount int       `json:"edited_count"`
+	Keyword     string
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// querier is what *sql.DB and *sql.Tx have in common, so a helper can run
+// inside a caller's transaction or on its own.
+//
+// Statements are written for both SQLite and Postgres, which the stores run
+// them on. They use $n placeholders, numbered in the order they first appear
+// in the statement, since go-sqlite3 binds them by position.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func scanReview(row rowScanner, review *Review) error {
+	err := row.Scan(
+		&review.ID,
+		&review.Content,
+		&review.Status,
+		&review.Sentiment,
+		&review.SentimentScore,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&review.EditedCount,
+	)
+	// Postgres hands zoneless timestamps back in a zone of its own.
+	review.CreatedAt, review.UpdatedAt = review.CreatedAt.UTC(), review.UpdatedAt.UTC()
+	return err
+}
+
+// scoreReview overwrites whatever sentiment the client sent with the one
+// computed from the review content.
+func scoreReview(review *Review) {
+	result := sentiment.Score(review.Content)
+	review.Sentiment = result.Label
+	review.SentimentScore = result.Score
 }
 
 func GetAllReviews(db *sql.DB) ([]*Review, error) {
 	var allReviews []*Review
 
-	statement := "SELECT review_id, review FROM review"
-	rows, err := db.Query(statement)
-	if err == sql.ErrNoRows {
+	statement := "SELECT " + reviewColumns + " FROM review WHERE status = $1 ORDER BY review_id"
+	rows, err := db.Query(statement, StatusApproved)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		review := Review{}
-		_ = rows.Scan(
-			&review.ID,
-			&review.Content,
-		)
+		_ = scanReview(rows, &review)
 		allReviews = append(allReviews, &review)
 	}
 
@@ -36,21 +97,45 @@ func GetAllReviews(db *sql.DB) ([]*Review, error) {
 func GetReview(db *sql.DB, reviewID uint) (*Review, error) {
 	review := Review{}
 
-	statement := "SELECT review_id, review FROM review WHERE review_id = ?"
+	statement := "SELECT " + reviewColumns + " FROM review WHERE review_id = $1"
 	row := db.QueryRow(statement, reviewID)
-	err := row.Scan(&review.ID, &review.Content)
-	if err == sql.ErrNoRows {
+	err := scanReview(row, &review)
+	if err != nil {
 		return nil, err
 	}
 
 	return &review, nil
 }
 
-func GetReviewsByKeyword(db *sql.DB, keyword string) ([]*Review, error) {
+// KeywordMatch returns the condition matching reviews that mention keyword,
+// which is passed as the placeholder param. It is where the stores' searches
+// differ.
+type KeywordMatch func(keyword string, param string) string
+
+// ContainsKeyword matches reviews containing keyword anywhere, even inside a
+// longer word, ignoring ASCII case.
+func ContainsKeyword(keyword string, param string) string {
+	return "review LIKE '%' || " + param + " || '%'"
+}
+
+// GetReviewsByKeyword returns approved reviews mentioning keyword, as match
+// finds them. A non-empty sentimentLabel further restricts them to that
+// sentiment.
+func GetReviewsByKeyword(db *sql.DB, match KeywordMatch, keyword string, sentimentLabel string) ([]*Review, error) {
 	var targetReviews []*Review
 
-	statement := "SELECT review_id, review FROM review WHERE review LIKE '%" + keyword + "%'"
-	rows, err := db.Query(statement)
+	statement := "SELECT " + reviewColumns + " FROM review WHERE status = $1 AND " + match(keyword, "$2")
+	args := []interface{}{StatusApproved, keyword}
+	if sentimentLabel != "" {
+		if !sentiment.ValidLabel(sentimentLabel) {
+			return nil, ErrInvalidSentiment
+		}
+		statement += " AND sentiment = $3"
+		args = append(args, sentimentLabel)
+	}
+	statement += " ORDER BY review_id"
+
+	rows, err := db.Query(statement, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +143,7 @@ func GetReviewsByKeyword(db *sql.DB, keyword string) ([]*Review, error) {
 
 	for rows.Next() {
 		review := Review{}
-		_ = rows.Scan(
-			&review.ID,
-			&review.Content,
-		)
+		_ = scanReview(rows, &review)
 		review.Keyword = keyword
 
 		targetReviews = append(targetReviews, &review)
@@ -76,7 +158,7 @@ func GetReviewsByKeyword(db *sql.DB, keyword string) ([]*Review, error) {
 
 func KeywordExists(db *sql.DB, keyword string) (bool, error) {
 	var foodKey string
-	statement := "SELECT keyword FROM dictionary WHERE keyword = ?"
+	statement := "SELECT keyword FROM dictionary WHERE keyword = $1"
 	row := db.QueryRow(statement, keyword)
 	err := row.Scan(&foodKey)
 
@@ -89,35 +171,187 @@ func KeywordExists(db *sql.DB, keyword string) (bool, error) {
 	return true, nil
 }
 
-func UpdateReview(db *sql.DB, reviewID uint, reviewBody []byte) error {
-	editedReview := Review{ID: reviewID}
+// ParseReview reads a submitted review body. New and edited reviews always
+// wait for a moderator, whatever status the client sent, and the sentiment is
+// computed from the content.
+func ParseReview(reviewBody []byte) (*Review, error) {
+	review := Review{}
+
+	err := json.Unmarshal(reviewBody, &review)
+	if err != nil {
+		return nil, err
+	}
+	review.ID = 0
+	review.Status = StatusPending
+	review.CreatedAt, review.UpdatedAt = time.Time{}, time.Time{}
+	review.EditedCount = 0
+	scoreReview(&review)
+
+	return &review, nil
+}
+
+// CreateReview stores a new pending review and raises flags on it, such as
+// the ones SpamFlags gives for the handler's spam verdict.
+func CreateReview(db *sql.DB, reviewBody []byte, flags []Flag) (*Review, error) {
+	newReview, err := ParseReview(reviewBody)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(newReview.Content) == "" {
+		return nil, ErrEmptyReview
+	}
+
+	// The review is written and flagged in one transaction, so it is either
+	// stored with its flags or not at all and can be sent again.
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	err := json.Unmarshal(reviewBody, &editedReview)
+	contentHash := fingerprint.ContentHash(newReview.Content)
+	simHash := fingerprint.SimHash(newReview.Content)
+	duplicateID, err := findExactDuplicate(tx, contentHash, 0)
+	if err != nil {
+		return nil, err
+	} else if duplicateID != 0 {
+		return nil, ErrDuplicateReview
+	}
+
+	// lib/pq has no LastInsertId, so the ID comes back with RETURNING.
+	now := time.Now().UTC().Truncate(time.Second)
+	insertStatement := "INSERT INTO review (review, status, sentiment, sentiment_score, content_hash, simhash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING review_id"
+	err = tx.QueryRow(
+		insertStatement,
+		newReview.Content,
+		newReview.Status,
+		newReview.Sentiment,
+		newReview.SentimentScore,
+		contentHash,
+		int64(simHash),
+		now.Format(dbTime),
+		now.Format(dbTime),
+	).Scan(&newReview.ID)
+	if err != nil {
+		return nil, err
+	}
+	newReview.CreatedAt, newReview.UpdatedAt = now, now
+
+	err = flagNearDuplicates(tx, newReview.ID, simHash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertFlags(tx, newReview.ID, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return newReview, nil
+}
+
+// UpdateReview replaces a review's content, moving its updated_at and
+// counting the edit, and raises flags on it like CreateReview. It returns
+// sql.ErrNoRows when there is no such review.
+func UpdateReview(db *sql.DB, reviewID uint, reviewBody []byte, flags []Flag) error {
+	editedReview, err := ParseReview(reviewBody)
 	if err != nil {
 		return err
 	}
+	editedReview.ID = reviewID
 
-	ps, err := db.Begin()
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	updateStatement := "UPDATE review SET review = ? WHERE review_id = ?"
-	statement, err := db.Prepare(updateStatement)
+	contentHash := fingerprint.ContentHash(editedReview.Content)
+	simHash := fingerprint.SimHash(editedReview.Content)
+	duplicateID, err := findExactDuplicate(tx, contentHash, reviewID)
+	if err != nil {
+		return err
+	} else if duplicateID != 0 {
+		return ErrDuplicateReview
+	}
+
+	updateStatement := "UPDATE review SET review = $1, status = $2, sentiment = $3, sentiment_score = $4, content_hash = $5, simhash = $6, updated_at = $7, edited_count = edited_count + 1 WHERE review_id = $8"
+	statement, err := tx.Prepare(updateStatement)
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(editedReview.Content, editedReview.ID)
+	result, err := statement.Exec(
+		editedReview.Content,
+		editedReview.Status,
+		editedReview.Sentiment,
+		editedReview.SentimentScore,
+		contentHash,
+		int64(simHash),
+		time.Now().UTC().Format(dbTime),
+		editedReview.ID,
+	)
 	if err != nil {
 		return err
 	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return err
+	} else if updated == 0 {
+		return sql.ErrNoRows
+	}
 
-	err = ps.Commit()
+	err = flagNearDuplicates(tx, editedReview.ID, simHash)
 	if err != nil {
 		return err
 	}
 
+	err = insertFlags(tx, editedReview.ID, flags)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteReview removes a review along with its flags, audit entries and
+// keyword mentions. It returns sql.ErrNoRows when there is no such review.
+func DeleteReview(db *sql.DB, reviewID uint) error {
+	if _, err := GetReview(db, reviewID); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = deleteReview(tx, reviewID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func deleteReview(tx *sql.Tx, reviewID uint) error {
+	for _, statement := range []string{
+		"DELETE FROM keyword_mention WHERE review_id = $1",
+		"DELETE FROM review_flag WHERE review_id = $1",
+		"DELETE FROM review_audit WHERE review_id = $1",
+		"DELETE FROM review WHERE review_id = $1",
+	} {
+		_, err := tx.Exec(statement, reviewID)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
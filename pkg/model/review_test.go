@@ -4,20 +4,26 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 
+	"food-review/pkg/fingerprint"
 	"food-review/pkg/model"
+	"food-review/pkg/sentiment"
 )
 
+// reviewedAt fills the created_at and updated_at columns of mocked reviews.
+var reviewedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
 func TestGetAllReviews(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
 		t.Error(err)
 	}
 
-	statement := "SELECT review_id, review FROM review"
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 ORDER BY review_id"
 
 	t.Run("No Review", func(t *testing.T) {
 		mock.ExpectQuery(statement).
@@ -35,9 +41,9 @@ func TestGetAllReviews(t *testing.T) {
 		var fakeReviewID1 uint = 666
 		var fakeReviewContent1 string = "That restaurant is underrated"
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow(fakeReviewID0, fakeReviewContent0).
-			AddRow(fakeReviewID1, fakeReviewContent1)
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow(fakeReviewID0, fakeReviewContent0, model.StatusApproved, "neutral", 0.0, reviewedAt, reviewedAt, 0).
+			AddRow(fakeReviewID1, fakeReviewContent1, model.StatusApproved, "neutral", 0.0, reviewedAt, reviewedAt, 0)
 
 		mock.ExpectQuery(statement).
 			WillReturnRows(mockRow)
@@ -57,7 +63,7 @@ func TestGetReview(t *testing.T) {
 		t.Error(err)
 	}
 
-	statement := "SELECT review_id, review FROM review WHERE review_id = ?"
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1"
 
 	t.Run("No Review Found", func(t *testing.T) {
 		var id uint = 0
@@ -76,8 +82,8 @@ func TestGetReview(t *testing.T) {
 		var id uint = 1
 		var content string = "Gordan Ramsey is crying"
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow(id, content)
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow(id, content, model.StatusApproved, "neutral", 0.0, reviewedAt, reviewedAt, 0)
 
 		mock.ExpectQuery(statement).
 			WithArgs(id).
@@ -96,15 +102,15 @@ func TestGetReviewsByKeyword(t *testing.T) {
 		t.Error(err)
 	}
 
-	statementFirstHalf := "SELECT review_id, review FROM review WHERE review LIKE '%"
-	statementSecondHalf := "%'"
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 AND review LIKE '%' || $2 || '%' ORDER BY review_id"
 
 	t.Run("Some DB Error", func(t *testing.T) {
 		keyword := "'"
-		mock.ExpectQuery(statementFirstHalf + keyword + statementSecondHalf).
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, keyword).
 			WillReturnError(errors.New("Unknown error"))
 
-		reviews, err := model.GetReviewsByKeyword(db, keyword)
+		reviews, err := model.GetReviewsByKeyword(db, model.ContainsKeyword, keyword, "")
 		if assert.Error(t, err) {
 			assert.Nil(t, reviews)
 			assert.ErrorContains(t, err, "Unknown")
@@ -113,11 +119,12 @@ func TestGetReviewsByKeyword(t *testing.T) {
 
 	t.Run("No Review Found", func(t *testing.T) {
 		keyword := "cockroach"
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"})
-		mock.ExpectQuery(statementFirstHalf + keyword + statementSecondHalf).
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"})
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, keyword).
 			WillReturnRows(mockRow)
 
-		reviews, err := model.GetReviewsByKeyword(db, keyword)
+		reviews, err := model.GetReviewsByKeyword(db, model.ContainsKeyword, keyword, "")
 		if assert.Error(t, err) {
 			assert.Nil(t, reviews)
 			assert.ErrorIs(t, err, sql.ErrNoRows)
@@ -126,13 +133,14 @@ func TestGetReviewsByKeyword(t *testing.T) {
 
 	t.Run("Happy Path", func(t *testing.T) {
 		keyword := "tiramisu"
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow(uint(11111), "Worst tiramisu").
-			AddRow(uint(22222), "Best tiramisu")
-		mock.ExpectQuery(statementFirstHalf + keyword + statementSecondHalf).
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow(uint(11111), "Worst tiramisu", model.StatusApproved, "neutral", 0.0, reviewedAt, reviewedAt, 0).
+			AddRow(uint(22222), "Best tiramisu", model.StatusApproved, "neutral", 0.0, reviewedAt, reviewedAt, 0)
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, keyword).
 			WillReturnRows(mockRow)
 
-		reviews, err := model.GetReviewsByKeyword(db, keyword)
+		reviews, err := model.GetReviewsByKeyword(db, model.ContainsKeyword, keyword, "")
 		if assert.NoError(t, err) {
 			assert.Len(t, reviews, 2)
 			assert.Equal(t, reviews[0].ID, uint(11111))
@@ -141,13 +149,43 @@ func TestGetReviewsByKeyword(t *testing.T) {
 	})
 }
 
+func TestGetReviewsByKeywordSentiment(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 AND review LIKE '%' || $2 || '%' AND sentiment = $3 ORDER BY review_id"
+
+	t.Run("Invalid Sentiment", func(t *testing.T) {
+		reviews, err := model.GetReviewsByKeyword(db, model.ContainsKeyword, "curry", "angry")
+		if assert.ErrorIs(t, err, model.ErrInvalidSentiment) {
+			assert.Nil(t, reviews)
+		}
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow(uint(5), "Bland curry", model.StatusApproved, sentiment.Negative, -0.45, reviewedAt, reviewedAt, 0)
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, "curry", sentiment.Negative).
+			WillReturnRows(mockRow)
+
+		reviews, err := model.GetReviewsByKeyword(db, model.ContainsKeyword, "curry", sentiment.Negative)
+		if assert.NoError(t, err) && assert.Len(t, reviews, 1) {
+			assert.Equal(t, sentiment.Negative, reviews[0].Sentiment)
+			assert.Equal(t, -0.45, reviews[0].SentimentScore)
+		}
+	})
+}
+
 func TestKeywordExists(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
 		t.Error(err)
 	}
 
-	statement := "SELECT keyword FROM dictionary WHERE keyword = ?"
+	statement := "SELECT keyword FROM dictionary WHERE keyword = $1"
 
 	t.Run("Keyword Not Present in Dict", func(t *testing.T) {
 		keyword := "tasty cockroach"
@@ -198,11 +236,15 @@ func TestUpdateReview(t *testing.T) {
 		t.Error(err)
 	}
 
+	duplicateStatement := "SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1"
+	updateStatement := "UPDATE review SET review = $1, status = $2, sentiment = $3, sentiment_score = $4, content_hash = $5, simhash = $6, updated_at = $7, edited_count = edited_count + 1 WHERE review_id = $8"
+	fingerprintStatement := "SELECT review_id, simhash FROM review WHERE content_hash != '' ORDER BY review_id"
+
 	t.Run("JSON Incorrect Data Type", func(t *testing.T) {
 		var reviewID uint = 1
 		reviewBody := []byte(`{"review": true}`)
 
-		err := model.UpdateReview(db, reviewID, reviewBody)
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
 		expectedError := "cannot unmarshal bool"
 
 		if assert.Error(t, err) {
@@ -210,11 +252,25 @@ func TestUpdateReview(t *testing.T) {
 		}
 	})
 
+	t.Run("Duplicate Content", func(t *testing.T) {
+		var reviewID uint = 1
+		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WithArgs(fingerprint.ContentHash("This restaurant sucks"), reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(2))
+		mock.ExpectRollback()
+
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
+		assert.ErrorIs(t, err, model.ErrDuplicateReview)
+	})
+
 	t.Run("Transaction Begin Problem", func(t *testing.T) {
 		var reviewID uint = 1
 		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
 
-		err := model.UpdateReview(db, reviewID, reviewBody)
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
 		expectedError := "call to database transaction Begin was not expected"
 
 		if assert.Error(t, err) {
@@ -227,9 +283,12 @@ func TestUpdateReview(t *testing.T) {
 		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
 
 		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
 
-		err := model.UpdateReview(db, reviewID, reviewBody)
-		expectedError := "query was not expected"
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
+		expectedError := "was not expected"
 
 		if assert.Error(t, err) {
 			assert.ErrorContains(t, err, expectedError)
@@ -241,31 +300,63 @@ func TestUpdateReview(t *testing.T) {
 		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
 
 		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectPrepare(updateStatement).
+			ExpectExec().
+			WillReturnError(errors.New("disk I/O error"))
+		mock.ExpectRollback()
 
-		mock.ExpectPrepare("UPDATE review SET review = ? WHERE review_id = ?")
-
-		err := model.UpdateReview(db, reviewID, reviewBody)
-		expectedError := "was not expected"
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
+		expectedError := "disk I/O"
 
 		if assert.Error(t, err) {
 			assert.ErrorContains(t, err, expectedError)
 		}
 	})
 
-	t.Run("Transaction Commit Problem", func(t *testing.T) {
+	t.Run("Flagging Problem Keeps Nothing", func(t *testing.T) {
 		var reviewID uint = 1
 		content := "This restaurant sucks"
 		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
 
 		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectPrepare(updateStatement).
+			ExpectExec().
+			WithArgs(content, model.StatusPending, sentiment.Negative, sqlmock.AnyArg(), fingerprint.ContentHash(content), sqlmock.AnyArg(), sqlmock.AnyArg(), reviewID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnError(errors.New("disk I/O error"))
+		mock.ExpectRollback()
 
-		mock.ExpectPrepare("UPDATE review SET review = ? WHERE review_id = ?").
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
+		if assert.Error(t, err) {
+			assert.ErrorContains(t, err, "disk I/O")
+			assert.NoError(t, mock.ExpectationsWereMet(), "the edit is rolled back")
+		}
+	})
+
+	t.Run("Transaction Commit Problem", func(t *testing.T) {
+		var reviewID uint = 1
+		content := "This restaurant sucks"
+		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectPrepare(updateStatement).
 			ExpectExec().
-			WithArgs(content, reviewID).
+			WithArgs(content, model.StatusPending, sentiment.Negative, sqlmock.AnyArg(), fingerprint.ContentHash(content), sqlmock.AnyArg(), sqlmock.AnyArg(), reviewID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}))
+		mock.ExpectCommit().
+			WillReturnError(errors.New("database is locked"))
 
-		err := model.UpdateReview(db, reviewID, reviewBody)
-		expectedError := "was not expected"
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
+		expectedError := "database is locked"
 
 		if assert.Error(t, err) {
 			assert.ErrorContains(t, err, expectedError)
@@ -278,19 +369,183 @@ func TestUpdateReview(t *testing.T) {
 		reviewBody := []byte(`{"review": "This restaurant sucks"}`)
 
 		mock.ExpectBegin()
-
-		mock.ExpectPrepare("UPDATE review SET review = ? WHERE review_id = ?").
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectPrepare(updateStatement).
 			ExpectExec().
-			WithArgs(content, reviewID).
+			WithArgs(content, model.StatusPending, sentiment.Negative, sqlmock.AnyArg(), fingerprint.ContentHash(content), sqlmock.AnyArg(), sqlmock.AnyArg(), reviewID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}).
+				AddRow(1, int64(fingerprint.SimHash(content))).
+				AddRow(2, int64(fingerprint.SimHash("Lovely brunch spot with great coffee"))))
+		mock.ExpectCommit()
 
-		mock.ExpectCommit().
-			WillReturnError(nil)
+		err := model.UpdateReview(db, reviewID, reviewBody, nil)
+
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("No Such Review", func(t *testing.T) {
+		var reviewID uint = 42
+		content := "Ghost kitchen"
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectPrepare(updateStatement).
+			ExpectExec().
+			WithArgs(content, model.StatusPending, sentiment.Neutral, sqlmock.AnyArg(), fingerprint.ContentHash(content), sqlmock.AnyArg(), sqlmock.AnyArg(), reviewID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := model.UpdateReview(db, reviewID, []byte(`{"review": "Ghost kitchen"}`), nil)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteReview(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	t.Run("No Such Review", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(9)).
+			WillReturnError(sql.ErrNoRows)
+
+		err := model.DeleteReview(db, 9)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(3)).
+			WillReturnRows(reviewRow(3, "Crispy roti", model.StatusApproved))
+		mock.ExpectBegin()
+		for _, statement := range []string{
+			"DELETE FROM keyword_mention WHERE review_id = $1",
+			"DELETE FROM review_flag WHERE review_id = $1",
+			"DELETE FROM review_audit WHERE review_id = $1",
+			"DELETE FROM review WHERE review_id = $1",
+		} {
+			mock.ExpectExec(statement).
+				WithArgs(uint(3)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		err := model.DeleteReview(db, 3)
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
 
-		err := model.UpdateReview(db, reviewID, reviewBody)
+func TestCreateReview(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	statement := "INSERT INTO review (review, status, sentiment, sentiment_score, content_hash, simhash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING review_id"
+	duplicateStatement := "SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1"
+	fingerprintStatement := "SELECT review_id, simhash FROM review WHERE content_hash != '' ORDER BY review_id"
+
+	t.Run("JSON Incorrect Data Type", func(t *testing.T) {
+		reviewBody := []byte(`{"review": 42}`)
 
+		review, err := model.CreateReview(db, reviewBody, nil)
+		if assert.Error(t, err) {
+			assert.ErrorContains(t, err, "cannot unmarshal number")
+			assert.Nil(t, review)
+		}
+	})
+
+	t.Run("Empty Review", func(t *testing.T) {
+		reviewBody := []byte(`{"review": "   "}`)
+
+		review, err := model.CreateReview(db, reviewBody, nil)
+		if assert.ErrorIs(t, err, model.ErrEmptyReview) {
+			assert.Nil(t, review)
+		}
+	})
+
+	t.Run("Exact Duplicate", func(t *testing.T) {
+		reviewBody := []byte(`{"review": "CRISPY pork belly!"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WithArgs(fingerprint.ContentHash("crispy pork belly"), 0).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(1))
+		mock.ExpectRollback()
+
+		review, err := model.CreateReview(db, reviewBody, nil)
+		if assert.ErrorIs(t, err, model.ErrDuplicateReview) {
+			assert.Nil(t, review)
+		}
+	})
+
+	t.Run("Insert Problem", func(t *testing.T) {
+		reviewBody := []byte(`{"review": "Crispy pork belly"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(statement).
+			WillReturnError(errors.New("disk I/O error"))
+		mock.ExpectRollback()
+
+		review, err := model.CreateReview(db, reviewBody, nil)
+		if assert.Error(t, err) {
+			assert.ErrorContains(t, err, "disk I/O")
+			assert.Nil(t, review)
+		}
+	})
+
+	t.Run("Flagging Problem Keeps Nothing", func(t *testing.T) {
+		reviewBody := []byte(`{"review": "Crispy pork belly"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(statement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(3))
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnError(errors.New("disk I/O error"))
+		mock.ExpectRollback()
+
+		review, err := model.CreateReview(db, reviewBody, nil)
+		if assert.Error(t, err) {
+			assert.Nil(t, review)
+			assert.NoError(t, mock.ExpectationsWereMet(), "the review is rolled back, so it can be sent again")
+		}
+	})
+
+	t.Run("Happy Path Ignores Client Status", func(t *testing.T) {
+		content := "Crispy pork belly"
+		reviewBody := []byte(`{"review_id": 7, "review": "Crispy pork belly", "status": "approved"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(statement).
+			WithArgs(content, model.StatusPending, sentiment.Positive, sqlmock.AnyArg(), fingerprint.ContentHash(content), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(3))
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}).
+				AddRow(3, int64(fingerprint.SimHash(content))))
+		mock.ExpectCommit()
+
+		review, err := model.CreateReview(db, reviewBody, nil)
 		if assert.NoError(t, err) {
-			assert.Nil(t, err)
+			assert.Equal(t, uint(3), review.ID)
+			assert.Equal(t, model.StatusPending, review.Status)
+			assert.NoError(t, mock.ExpectationsWereMet())
 		}
 	})
 }
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"database/sql"
+	"sort"
+
+	"food-review/pkg/sentiment"
+)
+
+type KeywordSentiment struct {
+	Keyword  string
+	Positive int
+	Negative int
+	Neutral  int
+}
+
+func (ks *KeywordSentiment) Total() int {
+	return ks.Positive + ks.Negative + ks.Neutral
+}
+
+// NegativeShare is the fraction of reviews mentioning the keyword that are negative.
+func (ks *KeywordSentiment) NegativeShare() float64 {
+	if ks.Total() == 0 {
+		return 0
+	}
+	return float64(ks.Negative) / float64(ks.Total())
+}
+
+// GetKeywordSentiments counts approved reviews per sentiment for each keyword,
+// as match finds them, most disliked keywords first. Keywords nobody mentions
+// are left out.
+func GetKeywordSentiments(db *sql.DB, match KeywordMatch, keywords []string) ([]*KeywordSentiment, error) {
+	var breakdown []*KeywordSentiment
+
+	for _, keyword := range keywords {
+		statement := "SELECT sentiment, COUNT(*) FROM review WHERE status = $1 AND " + match(keyword, "$2") + " GROUP BY sentiment"
+		rows, err := db.Query(statement, StatusApproved, keyword)
+		if err != nil {
+			return nil, err
+		}
+
+		ks := &KeywordSentiment{Keyword: keyword}
+		for rows.Next() {
+			var label string
+			var count int
+			if err := rows.Scan(&label, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			switch label {
+			case sentiment.Positive:
+				ks.Positive = count
+			case sentiment.Negative:
+				ks.Negative = count
+			default:
+				ks.Neutral += count
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		breakdown = append(breakdown, ks)
+	}
+
+	return RankKeywordSentiments(breakdown), nil
+}
+
+// RankKeywordSentiments drops keywords nobody mentions and orders the rest
+// most disliked first.
+func RankKeywordSentiments(breakdown []*KeywordSentiment) []*KeywordSentiment {
+	var ranked []*KeywordSentiment
+	for _, ks := range breakdown {
+		if ks.Total() > 0 {
+			ranked = append(ranked, ks)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].NegativeShare() > ranked[j].NegativeShare()
+	})
+
+	return ranked
+}
+
+// maxExcerpts caps how many example excerpts an AspectReport keeps per polarity.
+const maxExcerpts = 3
+
+type Excerpt struct {
+	ReviewID uint
+	Text     string
+}
+
+type AspectReport struct {
+	Keyword          string
+	Positive         int
+	Negative         int
+	Neutral          int
+	PositiveExcerpts []Excerpt
+	NegativeExcerpts []Excerpt
+}
+
+// GetKeywordAspects scores every mention of keyword across approved reviews,
+// as match finds them, by the clause it appears in rather than the review as
+// a whole.
+func GetKeywordAspects(db *sql.DB, match KeywordMatch, keyword string) (*AspectReport, error) {
+	reviews, err := GetReviewsByKeyword(db, match, keyword, "")
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return BuildAspectReport(keyword, reviews), nil
+}
+
+// BuildAspectReport scores the mentions of keyword in reviews.
+func BuildAspectReport(keyword string, reviews []*Review) *AspectReport {
+	report := &AspectReport{Keyword: keyword}
+
+	for _, review := range reviews {
+		for _, mention := range sentiment.Aspects(review.Content, []string{keyword}) {
+			excerpt := Excerpt{ReviewID: review.ID, Text: mention.Excerpt}
+
+			switch mention.Label {
+			case sentiment.Positive:
+				report.Positive++
+				if len(report.PositiveExcerpts) < maxExcerpts {
+					report.PositiveExcerpts = append(report.PositiveExcerpts, excerpt)
+				}
+			case sentiment.Negative:
+				report.Negative++
+				if len(report.NegativeExcerpts) < maxExcerpts {
+					report.NegativeExcerpts = append(report.NegativeExcerpts, excerpt)
+				}
+			default:
+				report.Neutral++
+			}
+		}
+	}
+
+	return report
+}
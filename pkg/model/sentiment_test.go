@@ -0,0 +1,91 @@
+package model_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/model"
+	"food-review/pkg/sentiment"
+)
+
+func TestGetKeywordSentiments(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	statement := "SELECT sentiment, COUNT(*) FROM review WHERE status = $1 AND review LIKE '%' || $2 || '%' GROUP BY sentiment"
+
+	t.Run("DB Error", func(t *testing.T) {
+		mock.ExpectQuery(statement).
+			WillReturnError(errors.New("database is locked"))
+
+		breakdown, err := model.GetKeywordSentiments(db, model.ContainsKeyword, []string{"curry"})
+		if assert.Error(t, err) {
+			assert.Nil(t, breakdown)
+		}
+	})
+
+	t.Run("Most Disliked First", func(t *testing.T) {
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, "curry").
+			WillReturnRows(sqlmock.NewRows([]string{"sentiment", "count"}).
+				AddRow(sentiment.Positive, 3).
+				AddRow(sentiment.Negative, 1))
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, "rice").
+			WillReturnRows(sqlmock.NewRows([]string{"sentiment", "count"}).
+				AddRow(sentiment.Negative, 2).
+				AddRow(sentiment.Neutral, 2))
+		mock.ExpectQuery(statement).
+			WithArgs(model.StatusApproved, "durian").
+			WillReturnRows(sqlmock.NewRows([]string{"sentiment", "count"}))
+
+		breakdown, err := model.GetKeywordSentiments(db, model.ContainsKeyword, []string{"curry", "rice", "durian"})
+		if assert.NoError(t, err) && assert.Len(t, breakdown, 2) {
+			assert.Equal(t, "rice", breakdown[0].Keyword)
+			assert.Equal(t, 0.5, breakdown[0].NegativeShare())
+			assert.Equal(t, "curry", breakdown[1].Keyword)
+			assert.Equal(t, 4, breakdown[1].Total())
+		}
+	})
+}
+
+func TestGetKeywordAspects(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 AND review LIKE '%' || $2 || '%' ORDER BY review_id"
+
+	t.Run("No Mentions", func(t *testing.T) {
+		mock.ExpectQuery(statement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}))
+
+		report, err := model.GetKeywordAspects(db, model.ContainsKeyword, "rice")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "rice", report.Keyword)
+			assert.Zero(t, report.Positive+report.Negative+report.Neutral)
+		}
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectQuery(statement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow(1, "The curry was great but the rice was soggy", model.StatusApproved, sentiment.Neutral, 0.0, reviewedAt, reviewedAt, 0).
+				AddRow(2, "Fragrant rice. Delicious rice!", model.StatusApproved, sentiment.Positive, 0.6, reviewedAt, reviewedAt, 0))
+
+		report, err := model.GetKeywordAspects(db, model.ContainsKeyword, "rice")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, report.Negative)
+			assert.Equal(t, 1, report.Positive)
+			assert.Equal(t, 1, report.Neutral)
+			assert.Equal(t, model.Excerpt{ReviewID: 1, Text: "the rice was soggy"}, report.NegativeExcerpts[0])
+			assert.Equal(t, "Delicious rice", report.PositiveExcerpts[0].Text)
+		}
+	})
+}
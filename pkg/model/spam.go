@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"strings"
+
+	"food-review/pkg/spam"
+)
+
+// FlagSpam is the reason given both by readers and by the spam checker.
+const FlagSpam = "spam"
+
+var ErrSpamRejected = errors.New("review was rejected as spam")
+
+// ScreenReview runs the spam checker over a submission. A nil checker lets
+// everything through.
+func ScreenReview(checker *spam.Checker, content string, sender string) (spam.Verdict, error) {
+	if checker == nil {
+		return spam.Verdict{}, nil
+	}
+
+	verdict := checker.Check(content, sender)
+	if verdict.Reject {
+		return verdict, ErrSpamRejected
+	}
+
+	return verdict, nil
+}
+
+// SpamFlags are the flags that put a review the checker scored as spam in
+// front of a moderator, noting the rules it tripped. There are none for a
+// review that isn't spam.
+func SpamFlags(verdict spam.Verdict) []Flag {
+	if !verdict.Spam {
+		return nil
+	}
+	return []Flag{{Reason: FlagSpam, Note: SpamNote(verdict)}}
+}
+
+// SpamNote is the note on a spam flag listing the rules a review tripped.
+func SpamNote(verdict spam.Verdict) string {
+	return strings.Join(verdict.Reasons, "; ")
+}
@@ -0,0 +1,69 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/model"
+	"food-review/pkg/spam"
+)
+
+func TestCreateReviewSpam(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	t.Run("Flagged", func(t *testing.T) {
+		verdict := spam.Verdict{Spam: true, Reasons: []string{`blacklisted phrase "casino"`}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("INSERT INTO review (review, status, sentiment, sentiment_score, content_hash, simhash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING review_id").
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(5))
+		mock.ExpectQuery(fingerprintStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}))
+		mock.ExpectExec("INSERT INTO review_flag (review_id, reason, note) VALUES ($1, $2, $3)").
+			WithArgs(uint(5), model.FlagSpam, `blacklisted phrase "casino"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(auditStatement).
+			WithArgs(uint(5), model.ActionFlagged, `spam: blacklisted phrase "casino"`, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		review, err := model.CreateReview(db, []byte(`{"review": "Great food, even better casino upstairs"}`), model.SpamFlags(verdict))
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.StatusPending, review.Status)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestScreenReview(t *testing.T) {
+	rules := spam.DefaultRules()
+	rules.RejectThreshold = 10
+	checker := spam.NewChecker(rules)
+
+	verdict, err := model.ScreenReview(checker, "Great food, even better casino upstairs", "10.0.0.1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.Flag{{Reason: model.FlagSpam, Note: `blacklisted phrase "casino"`}}, model.SpamFlags(verdict))
+	}
+
+	_, err = model.ScreenReview(checker, "Click here http://a.example http://b.example for free money at the casino", "10.0.0.1")
+	assert.Equal(t, model.ErrSpamRejected, err)
+
+	verdict, err = model.ScreenReview(nil, "casino casino casino", "10.0.0.1")
+	if assert.NoError(t, err) {
+		assert.Empty(t, model.SpamFlags(verdict))
+	}
+}
+
+func TestProfanityFlags(t *testing.T) {
+	assert.Equal(t, []model.Flag{{Reason: model.FlagOffensive, Note: "profanity: shit, เหี้ย"}},
+		model.ProfanityFlags([]string{"shit", "เหี้ย"}))
+	assert.Empty(t, model.ProfanityFlags(nil))
+}
@@ -0,0 +1,251 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// StatsListLimit caps the top and trending keyword lists.
+const StatsListLimit = 10
+
+// dbTime is the format timestamps are written and compared in. It matches
+// SQLite's CURRENT_TIMESTAMP, so created_at compares correctly as text there,
+// and Postgres reads it as a timestamp without a zone.
+const dbTime = "2006-01-02 15:04:05"
+
+type DayCount struct {
+	Day   string
+	Count int
+}
+
+type KeywordCount struct {
+	Keyword  string
+	Mentions int
+	Reviews  int
+}
+
+type KeywordTrend struct {
+	Keyword  string
+	Recent   int
+	Previous int
+}
+
+func (kt *KeywordTrend) Change() int {
+	return kt.Recent - kt.Previous
+}
+
+type Stats struct {
+	Days          int
+	TotalReviews  int
+	ReviewsPerDay []DayCount
+	TopKeywords   []KeywordCount
+	Trending      []KeywordTrend
+	Longest       *Review
+	Shortest      *Review
+}
+
+// CountMentions counts the case-insensitive occurrences of keyword in content.
+func CountMentions(content string, keyword string) int {
+	if keyword == "" {
+		return 0
+	}
+	return strings.Count(strings.ToLower(content), strings.ToLower(keyword))
+}
+
+// RefreshKeywordMentions recounts how often each dictionary keyword appears in
+// a review. It runs after every create or update so stats never need to
+// rescan review text.
+func RefreshKeywordMentions(db *sql.DB, reviewID uint, keywords []string) error {
+	review, err := GetReview(db, reviewID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteStatement := "DELETE FROM keyword_mention WHERE review_id = $1"
+	_, err = tx.Exec(deleteStatement, reviewID)
+	if err != nil {
+		return err
+	}
+
+	insertStatement := "INSERT INTO keyword_mention (review_id, keyword, mentions) VALUES ($1, $2, $3)"
+	for _, keyword := range keywords {
+		mentions := CountMentions(review.Content, keyword)
+		if mentions == 0 {
+			continue
+		}
+		_, err = tx.Exec(insertStatement, reviewID, keyword, mentions)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// IndexKeyword records the mentions of a newly added dictionary keyword in
+// the reviews written before it existed, among the reviews match finds.
+func IndexKeyword(db *sql.DB, match KeywordMatch, keyword string) error {
+	type mention struct {
+		reviewID uint
+		count    int
+	}
+	var found []mention
+
+	statement := "SELECT review_id, review FROM review WHERE " + match(keyword, "$1")
+	rows, err := db.Query(statement, keyword)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var reviewID uint
+		var content string
+		if err := rows.Scan(&reviewID, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if count := CountMentions(content, keyword); count > 0 {
+			found = append(found, mention{reviewID, count})
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertStatement := `INSERT INTO keyword_mention (review_id, keyword, mentions) VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, keyword) DO UPDATE SET mentions = excluded.mentions`
+	for _, m := range found {
+		_, err = tx.Exec(insertStatement, m.reviewID, keyword, m.count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetStats summarises approved reviews. Trending keywords compare mentions in
+// reviews written during the last days days against the days before that.
+func GetStats(db *sql.DB, days int, now time.Time) (*Stats, error) {
+	stats := &Stats{Days: days}
+	now = now.UTC()
+	recentStart := now.AddDate(0, 0, -days).Format(dbTime)
+	previousStart := now.AddDate(0, 0, -2*days).Format(dbTime)
+
+	totalStatement := "SELECT COUNT(*) FROM review WHERE status = $1"
+	err := db.QueryRow(totalStatement, StatusApproved).Scan(&stats.TotalReviews)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite and Postgres have no day function in common, so the reviews are
+	// counted per day here.
+	perDayStatement := "SELECT created_at FROM review WHERE status = $1 AND created_at >= $2 ORDER BY created_at"
+	err = queryEach(db, func(rows *sql.Rows) error {
+		var createdAt time.Time
+		if err := rows.Scan(&createdAt); err != nil {
+			return err
+		}
+		day := createdAt.UTC().Format("2006-01-02")
+		if last := len(stats.ReviewsPerDay) - 1; last >= 0 && stats.ReviewsPerDay[last].Day == day {
+			stats.ReviewsPerDay[last].Count++
+		} else {
+			stats.ReviewsPerDay = append(stats.ReviewsPerDay, DayCount{Day: day, Count: 1})
+		}
+		return nil
+	}, perDayStatement, StatusApproved, recentStart)
+	if err != nil {
+		return nil, err
+	}
+
+	topStatement := `SELECT km.keyword, SUM(km.mentions), COUNT(*) FROM keyword_mention km
+		JOIN review r ON r.review_id = km.review_id
+		WHERE r.status = $1
+		GROUP BY km.keyword ORDER BY SUM(km.mentions) DESC, km.keyword LIMIT $2`
+	err = queryEach(db, func(rows *sql.Rows) error {
+		top := KeywordCount{}
+		err := rows.Scan(&top.Keyword, &top.Mentions, &top.Reviews)
+		stats.TopKeywords = append(stats.TopKeywords, top)
+		return err
+	}, topStatement, StatusApproved, StatsListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Postgres can't refer to the sums by name in HAVING, hence the subquery.
+	trendingStatement := `SELECT keyword, recent, previous FROM (
+			SELECT km.keyword,
+			SUM(CASE WHEN r.created_at >= $1 THEN km.mentions ELSE 0 END) AS recent,
+			SUM(CASE WHEN r.created_at < $1 THEN km.mentions ELSE 0 END) AS previous
+			FROM keyword_mention km
+			JOIN review r ON r.review_id = km.review_id
+			WHERE r.status = $2 AND r.created_at >= $3
+			GROUP BY km.keyword
+		) counts
+		WHERE recent > previous
+		ORDER BY recent - previous DESC, keyword LIMIT $4`
+	err = queryEach(db, func(rows *sql.Rows) error {
+		trend := KeywordTrend{}
+		err := rows.Scan(&trend.Keyword, &trend.Recent, &trend.Previous)
+		stats.Trending = append(stats.Trending, trend)
+		return err
+	}, trendingStatement, recentStart, StatusApproved, previousStart, StatsListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Longest, err = getReviewByLength(db, "DESC")
+	if err != nil {
+		return nil, err
+	}
+	stats.Shortest, err = getReviewByLength(db, "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func getReviewByLength(db *sql.DB, order string) (*Review, error) {
+	review := Review{}
+
+	statement := "SELECT " + reviewColumns + " FROM review WHERE status = $1 ORDER BY length(review) " + order + ", review_id LIMIT 1"
+	err := scanReview(db.QueryRow(statement, StatusApproved), &review)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func queryEach(db querier, scan func(rows *sql.Rows) error, statement string, args ...interface{}) error {
+	rows, err := db.Query(statement, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,120 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/model"
+)
+
+func TestRefreshKeywordMentions(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	deleteStatement := "DELETE FROM keyword_mention WHERE review_id = $1"
+	insertStatement := "INSERT INTO keyword_mention (review_id, keyword, mentions) VALUES ($1, $2, $3)"
+
+	t.Run("No Review Found", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(3)).
+			WillReturnError(sql.ErrNoRows)
+
+		err := model.RefreshKeywordMentions(db, 3, []string{"curry"})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Counts Case Insensitive Mentions", func(t *testing.T) {
+		mock.ExpectQuery(getReviewStatement).
+			WithArgs(uint(3)).
+			WillReturnRows(reviewRow(3, "Green curry, red CURRY and rice", model.StatusPending))
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteStatement).
+			WithArgs(uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(insertStatement).
+			WithArgs(uint(3), "curry", 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(insertStatement).
+			WithArgs(uint(3), "rice", 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := model.RefreshKeywordMentions(db, 3, []string{"curry", "satay", "rice"})
+		if assert.NoError(t, err) {
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestGetStats(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Error(err)
+	}
+
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM review`).
+			WithArgs(model.StatusApproved).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		mock.ExpectQuery(`SELECT created_at FROM review`).
+			WithArgs(model.StatusApproved, "2024-01-08 12:00:00").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).
+				AddRow(time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC)).
+				AddRow(time.Date(2024, 1, 10, 18, 0, 0, 0, time.UTC)).
+				AddRow(time.Date(2024, 1, 14, 8, 0, 0, 0, time.UTC)))
+		mock.ExpectQuery(`SELECT km.keyword, SUM\(km.mentions\), COUNT\(\*\)`).
+			WithArgs(model.StatusApproved, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword", "mentions", "reviews"}).
+				AddRow("pad thai", 9, 6))
+		mock.ExpectQuery(`SELECT keyword, recent, previous FROM \(\s+SELECT km.keyword,\s+SUM\(CASE`).
+			WithArgs("2024-01-08 12:00:00", model.StatusApproved, "2024-01-01 12:00:00", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword", "recent", "previous"}).
+				AddRow("khao soi", 5, 1))
+		mock.ExpectQuery(`ORDER BY length\(review\) DESC`).
+			WillReturnRows(reviewRow(4, "A very long review about noodles", model.StatusApproved))
+		mock.ExpectQuery(`ORDER BY length\(review\) ASC`).
+			WillReturnRows(reviewRow(9, "Meh", model.StatusApproved))
+
+		stats, err := model.GetStats(db, 7, now)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 12, stats.TotalReviews)
+			if assert.Len(t, stats.ReviewsPerDay, 2) {
+				assert.Equal(t, 2, stats.ReviewsPerDay[0].Count)
+			}
+			assert.Equal(t, model.KeywordCount{Keyword: "pad thai", Mentions: 9, Reviews: 6}, stats.TopKeywords[0])
+			assert.Equal(t, 4, stats.Trending[0].Change())
+			assert.Equal(t, uint(4), stats.Longest.ID)
+			assert.Equal(t, uint(9), stats.Shortest.ID)
+		}
+	})
+
+	t.Run("No Reviews", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM review`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`SELECT created_at FROM review`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+		mock.ExpectQuery(`SELECT km.keyword, SUM\(km.mentions\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword", "mentions", "reviews"}))
+		mock.ExpectQuery(`SELECT keyword, recent, previous FROM \(\s+SELECT km.keyword,\s+SUM\(CASE`).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword", "recent", "previous"}))
+		mock.ExpectQuery(`ORDER BY length\(review\) DESC`).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`ORDER BY length\(review\) ASC`).
+			WillReturnError(sql.ErrNoRows)
+
+		stats, err := model.GetStats(db, 7, now)
+		if assert.NoError(t, err) {
+			assert.Zero(t, stats.TotalReviews)
+			assert.Nil(t, stats.Longest)
+			assert.Nil(t, stats.Shortest)
+		}
+	})
+}
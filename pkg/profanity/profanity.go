@@ -0,0 +1,416 @@
+package profanity
+
+import (
+	"bufio"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Modes decide what happens to a review containing profanity.
+const (
+	// ModeReject refuses the submission.
+	ModeReject = "reject"
+	// ModeMask stores the review as written and masks words when rendering.
+	ModeMask = "mask"
+	// ModeFlag stores the review and flags it for a moderator.
+	ModeFlag = "flag"
+)
+
+var (
+	ErrInvalidMode = errors.New("profanity mode must be one of reject, mask or flag")
+	ErrProfanity   = errors.New("review contains profanity")
+)
+
+//go:embed words/*.txt
+var wordsFS embed.FS
+
+// leet maps the digits and symbols commonly swapped for letters.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// suffixes are stripped from English tokens so word lists only need the base
+// form.
+var suffixes = []string{"s", "es", "ed", "er", "ers", "ing", "in", "y", "ty"}
+
+type Config struct {
+	Mode      string `json:"mode"`
+	WordLists struct {
+		English string `json:"en"`
+		Thai    string `json:"th"`
+	} `json:"word_lists"`
+}
+
+type Match struct {
+	Word string
+	// Start and End are byte offsets of the match in the original text.
+	Start int
+	End   int
+}
+
+type Filter struct {
+	Mode    string
+	English map[string]bool
+	Thai    []string
+
+	// squeezed maps English words with repeated letters collapsed back to the
+	// word, so stretched spellings still match.
+	squeezed map[string]string
+}
+
+// char is one rune of the text being checked, reduced to the letter it stands
+// for.
+type char struct {
+	r          rune
+	start, end int
+	thai       bool
+	// skip marks punctuation inserted inside a word, which is ignored.
+	skip bool
+}
+
+func NewFilter(mode string, english []string, thai []string) (*Filter, error) {
+	if mode != ModeReject && mode != ModeMask && mode != ModeFlag {
+		return nil, ErrInvalidMode
+	}
+
+	filter := &Filter{
+		Mode:     mode,
+		English:  map[string]bool{},
+		squeezed: map[string]string{},
+	}
+	for _, word := range english {
+		word = strings.ToLower(word)
+		filter.English[word] = true
+		filter.squeezed[squeeze(word)] = word
+	}
+	for _, word := range thai {
+		filter.Thai = append(filter.Thai, word)
+	}
+	// Prefer the longest Thai match where words overlap.
+	sort.Slice(filter.Thai, func(i, j int) bool {
+		return utf8.RuneCountInString(filter.Thai[i]) > utf8.RuneCountInString(filter.Thai[j])
+	})
+
+	return filter, nil
+}
+
+// DefaultFilter masks the bundled word lists.
+func DefaultFilter() *Filter {
+	english, err := readWords(wordsFS, "words/en.txt")
+	if err != nil {
+		panic(err)
+	}
+	thai, err := readWords(wordsFS, "words/th.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	filter, _ := NewFilter(ModeMask, english, thai)
+	return filter
+}
+
+// LoadFilter reads a JSON config naming the mode and the word list files,
+// which are relative to the config file. An empty path falls back to
+// DefaultFilter, and a word list left out of the config to the bundled one;
+// a named config or word list that is missing is an error.
+func LoadFilter(path string) (*Filter, error) {
+	if path == "" {
+		return DefaultFilter(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := os.DirFS(filepath.Dir(path))
+	english, err := readWordList(dir, config.WordLists.English, "words/en.txt")
+	if err != nil {
+		return nil, err
+	}
+	thai, err := readWordList(dir, config.WordLists.Thai, "words/th.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFilter(config.Mode, english, thai)
+}
+
+// readWordList reads the word list name in dir, or the bundled list when
+// name is empty.
+func readWordList(dir fs.FS, name string, bundled string) ([]string, error) {
+	if name == "" {
+		return readWords(wordsFS, bundled)
+	}
+	return readWords(dir, name)
+}
+
+func readWords(fsys fs.FS, name string) ([]string, error) {
+	file, err := fsys.Open(filepath.ToSlash(name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseWords(file)
+}
+
+func parseWords(r io.Reader) ([]string, error) {
+	var words []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	return words, scanner.Err()
+}
+
+// Find returns every profane word in text, in order.
+func (f *Filter) Find(text string) []Match {
+	var matches []Match
+	chars := split(text)
+
+	for start := 0; start < len(chars); {
+		end := start
+		for end < len(chars) && chars[end].r != ' ' {
+			end++
+		}
+		matches = append(matches, f.findEnglish(chars[start:end])...)
+		matches = append(matches, f.findThai(chars[start:end])...)
+		start = end + 1
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+	return matches
+}
+
+// Contains reports whether text has any profanity.
+func (f *Filter) Contains(text string) bool {
+	return len(f.Find(text)) > 0
+}
+
+// Words lists the distinct profane words in text.
+func (f *Filter) Words(text string) []string {
+	var words []string
+	seen := map[string]bool{}
+
+	for _, match := range f.Find(text) {
+		if !seen[match.Word] {
+			seen[match.Word] = true
+			words = append(words, match.Word)
+		}
+	}
+
+	return words
+}
+
+// Mask keeps the first letter of each profane word and stars out the rest,
+// e.g. "f***".
+func (f *Filter) Mask(text string) string {
+	var masked strings.Builder
+	last := 0
+
+	for _, match := range f.Find(text) {
+		if match.Start < last {
+			continue
+		}
+		masked.WriteString(text[last:match.Start])
+		for i, r := range text[match.Start:match.End] {
+			if i == 0 || unicode.IsSpace(r) {
+				masked.WriteRune(r)
+			} else {
+				masked.WriteRune('*')
+			}
+		}
+		last = match.End
+	}
+	masked.WriteString(text[last:])
+
+	return masked.String()
+}
+
+// Render is the template helper: it masks text in ModeMask and returns it
+// unchanged otherwise. A nil filter renders text unchanged.
+func (f *Filter) Render(text string) string {
+	if f == nil || f.Mode != ModeMask {
+		return text
+	}
+	return f.Mask(text)
+}
+
+// findEnglish matches a whitespace separated token. Latin letters, with leet
+// symbols read as letters and punctuation ignored, make up the candidate.
+func (f *Filter) findEnglish(token []char) []Match {
+	var word []rune
+	first, last := -1, -1
+
+	for i, c := range token {
+		if c.skip || c.thai {
+			continue
+		}
+		word = append(word, c.r)
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	if len(word) == 0 {
+		return nil
+	}
+
+	if matched, ok := f.matchEnglish(string(word)); ok {
+		return []Match{{Word: matched, Start: token[first].start, End: token[last].end}}
+	}
+	return nil
+}
+
+func (f *Filter) matchEnglish(token string) (string, bool) {
+	candidates := []string{token}
+	for _, suffix := range suffixes {
+		if stem := strings.TrimSuffix(token, suffix); stem != token && stem != "" {
+			candidates = append(candidates, stem)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if f.English[candidate] {
+			return candidate, true
+		}
+		// Only stretched spellings count, so "as" doesn't match "ass".
+		if word, ok := f.squeezed[squeeze(candidate)]; ok && len(candidate) >= len(word) {
+			return word, true
+		}
+	}
+
+	return "", false
+}
+
+// findThai looks for Thai words anywhere in the token's Thai letters.
+func (f *Filter) findThai(token []char) []Match {
+	var matches []Match
+	var thai []char
+
+	flush := func() {
+		if len(thai) == 0 {
+			return
+		}
+		runes := make([]rune, len(thai))
+		for i, c := range thai {
+			runes[i] = c.r
+		}
+
+		covered := make([]bool, len(runes))
+		for _, word := range f.Thai {
+			target := []rune(word)
+			for i := 0; i+len(target) <= len(runes); i++ {
+				if string(runes[i:i+len(target)]) != word || anyCovered(covered[i:i+len(target)]) {
+					continue
+				}
+				for j := i; j < i+len(target); j++ {
+					covered[j] = true
+				}
+				matches = append(matches, Match{
+					Word:  word,
+					Start: thai[i].start,
+					End:   thai[i+len(target)-1].end,
+				})
+			}
+		}
+		thai = thai[:0]
+	}
+
+	for _, c := range token {
+		switch {
+		case c.thai:
+			thai = append(thai, c)
+		case c.skip:
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return matches
+}
+
+func anyCovered(covered []bool) bool {
+	for _, c := range covered {
+		if c {
+			return true
+		}
+	}
+	return false
+}
+
+// split reduces text to the letters each rune stands for. Whitespace becomes
+// ' '; punctuation is kept only as a skipped char so offsets stay intact.
+func split(text string) []char {
+	var chars []char
+	runes := []rune(text)
+	offset := 0
+
+	for i, r := range runes {
+		size := utf8.RuneLen(r)
+		r = unicode.ToLower(r)
+		c := char{r: r, start: offset, end: offset + size}
+		offset += size
+
+		switch {
+		case unicode.IsSpace(r):
+			c.r = ' '
+		case unicode.Is(unicode.Thai, r):
+			c.thai = true
+		case unicode.IsLetter(r):
+		case leet[r] != 0:
+			c.r = leet[r]
+		case (r == '!' || r == '|') && i > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1]):
+			c.r = 'i'
+		default:
+			c.skip = true
+		}
+		chars = append(chars, c)
+	}
+
+	return chars
+}
+
+// squeeze collapses runs of the same letter, e.g. "fuuuck" to "fuck".
+func squeeze(word string) string {
+	var squeezed []rune
+	for _, r := range word {
+		if n := len(squeezed); n > 0 && squeezed[n-1] == r {
+			continue
+		}
+		squeezed = append(squeezed, r)
+	}
+	return string(squeezed)
+}
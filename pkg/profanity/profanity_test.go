@@ -0,0 +1,124 @@
+package profanity_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/profanity"
+)
+
+func TestFind(t *testing.T) {
+	filter := profanity.DefaultFilter()
+
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Clean", "The som tam was spicy and the sticky rice was perfect.", nil},
+		{"Plain", "What a shit service", []string{"shit"}},
+		{"Capitals and Suffix", "FUCKING slow kitchen", []string{"fuck"}},
+		{"Leetspeak", "the waiter was a b1tch and the chef an a$$hole", []string{"bitch", "asshole"}},
+		{"Inserted Punctuation", "f.u.c.k this place, sh!t food", []string{"fuck", "shit"}},
+		{"Stretched Letters", "fuuuuck that was cold", []string{"fuck"}},
+		{"Trailing Punctuation", "Total crap!", []string{"crap"}},
+		{"Not Inside Words", "A classic assessment of the passionfruit cocktail", nil},
+		{"Short Word Not Stretched", "as good as it gets", nil},
+		{"Thai", "อาหารห่วยเหี้ยมาก", []string{"เหี้ย"}},
+		{"Thai Inserted Punctuation", "ร้านนี้ สั.ส จริงๆ", []string{"สัส"}},
+		{"Thai Among English", "pad thaiควยมาก", []string{"ควย"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, filter.Words(tc.text))
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	filter := profanity.DefaultFilter()
+
+	assert.Equal(t, "What a s*** service", filter.Mask("What a shit service"))
+	assert.Equal(t, "f****** slow!", filter.Mask("f.u.c.k slow!"))
+	assert.Equal(t, "a b**** waiter", filter.Mask("a b1tch waiter"))
+	assert.Equal(t, "ห่วยเ****มาก", filter.Mask("ห่วยเหี้ยมาก"))
+	assert.Equal(t, "Lovely curry", filter.Mask("Lovely curry"))
+}
+
+func TestRender(t *testing.T) {
+	filter := profanity.DefaultFilter()
+	assert.Equal(t, "s***", filter.Render("shit"))
+
+	filter.Mode = profanity.ModeFlag
+	assert.Equal(t, "shit", filter.Render("shit"))
+
+	var none *profanity.Filter
+	assert.Equal(t, "shit", none.Render("shit"))
+}
+
+func TestLoadFilter(t *testing.T) {
+	t.Run("Missing Config", func(t *testing.T) {
+		_, err := profanity.LoadFilter(filepath.Join(t.TempDir(), "profanity.json"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("No Config Uses Defaults", func(t *testing.T) {
+		filter, err := profanity.LoadFilter("")
+		if assert.NoError(t, err) {
+			assert.Equal(t, profanity.ModeMask, filter.Mode)
+			assert.True(t, filter.Contains("shit"))
+		}
+	})
+
+	t.Run("Mode Only Uses Bundled Word Lists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "profanity.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"mode": "reject"}`), 0o644))
+
+		filter, err := profanity.LoadFilter(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, profanity.ModeReject, filter.Mode)
+			assert.True(t, filter.Contains("shit"))
+		}
+	})
+
+	t.Run("From Config", func(t *testing.T) {
+		dir := t.TempDir()
+		write := func(name string, content string) {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+		}
+		write("en.txt", "# custom\nheck\n")
+		write("th.txt", "บ้า\n")
+		write("profanity.json", `{"mode": "reject", "word_lists": {"en": "en.txt", "th": "th.txt"}}`)
+
+		filter, err := profanity.LoadFilter(filepath.Join(dir, "profanity.json"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, profanity.ModeReject, filter.Mode)
+			assert.Equal(t, []string{"heck", "บ้า"}, filter.Words("h3ck บ้าจริง"))
+			assert.False(t, filter.Contains("shit"))
+		}
+	})
+
+	t.Run("Invalid Mode", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "profanity.json")
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "words.txt"), []byte("heck\n"), 0o644))
+		assert.NoError(t, os.WriteFile(path, []byte(`{"mode": "shout", "word_lists": {"en": "words.txt", "th": "words.txt"}}`), 0o644))
+
+		_, err := profanity.LoadFilter(path)
+		assert.Equal(t, profanity.ErrInvalidMode, err)
+	})
+
+	t.Run("Missing Word List", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "profanity.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"mode": "flag", "word_lists": {"en": "en.txt", "th": "th.txt"}}`), 0o644))
+
+		_, err := profanity.LoadFilter(path)
+		assert.Error(t, err)
+	})
+}
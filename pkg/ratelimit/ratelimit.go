@@ -0,0 +1,197 @@
+// Package ratelimit throttles clients with token buckets. Each route has a
+// budget of requests per period, and every client gets its own bucket per
+// budget, refilled at a steady rate up to a burst size.
+package ratelimit
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Duration reads a time.Duration written as a string like "1m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+
+	return nil
+}
+
+// Budget allows Requests per Per on average, with up to Burst at once. Burst
+// defaults to Requests.
+type Budget struct {
+	Requests int      `json:"requests"`
+	Per      Duration `json:"per"`
+	Burst    int      `json:"burst"`
+}
+
+// Rules hold the budget for every route not listed in Routes, which are
+// keyed by method and route template, e.g. "PUT /reviews/{reviewID}".
+type Rules struct {
+	Default Budget            `json:"default"`
+	Routes  map[string]Budget `json:"routes"`
+}
+
+// Budget returns the name and budget that apply to a route.
+func (r Rules) Budget(method string, route string) (string, Budget) {
+	name := method + " " + route
+	if budget, ok := r.Routes[name]; ok {
+		return name, budget
+	}
+	return "default", r.Default
+}
+
+func ParseRules(data []byte) (Rules, error) {
+	rules := Rules{}
+
+	err := json.Unmarshal(data, &rules)
+	if err != nil {
+		return rules, err
+	}
+
+	rules.Default, err = rules.Default.normalize()
+	if err != nil {
+		return rules, fmt.Errorf("rate limits: default: %w", err)
+	}
+	for route, budget := range rules.Routes {
+		rules.Routes[route], err = budget.normalize()
+		if err != nil {
+			return rules, fmt.Errorf("rate limits: %s: %w", route, err)
+		}
+	}
+
+	return rules, nil
+}
+
+func (b Budget) normalize() (Budget, error) {
+	if b.Requests <= 0 || b.Per.Duration <= 0 {
+		return b, errors.New("requests and per must be positive")
+	}
+	if b.Burst < 0 {
+		return b, errors.New("burst must not be negative")
+	}
+	if b.Burst == 0 {
+		b.Burst = b.Requests
+	}
+	return b, nil
+}
+
+// LoadRules reads rules from path, or returns the bundled defaults when path
+// is empty. A path that was given but doesn't exist is an error.
+func LoadRules(path string) (Rules, error) {
+	if path == "" {
+		return DefaultRules(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+
+	rules, err := ParseRules(data)
+	if err != nil {
+		return Rules{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+func DefaultRules() Rules {
+	rules, err := ParseRules(defaultRules)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// Bucket is the state kept per client and budget.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+	// Full is when the bucket will have refilled completely, after which it
+	// can be forgotten.
+	Full time.Time
+}
+
+// Decision is the outcome of one request against its bucket.
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed; zero
+	// when this one was.
+	RetryAfter time.Duration
+}
+
+// take spends a token from bucket, which is nil for a client not seen before
+// or forgotten since.
+func (b Budget) take(bucket *Bucket, now time.Time) (Bucket, Decision) {
+	perToken := b.Per.Duration.Seconds() / float64(b.Requests)
+
+	tokens := float64(b.Burst)
+	if bucket != nil {
+		elapsed := now.Sub(bucket.Updated).Seconds()
+		tokens = math.Min(float64(b.Burst), bucket.Tokens+math.Max(elapsed, 0)/perToken)
+	}
+
+	decision := Decision{Limit: b.Burst}
+	if tokens >= 1 {
+		tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - tokens) * perToken)
+	}
+	decision.Remaining = int(tokens)
+	decision.Reset = seconds((float64(b.Burst) - tokens) * perToken)
+
+	return Bucket{Tokens: tokens, Updated: now, Full: now.Add(decision.Reset)}, decision
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store keeps the buckets. Take must apply the budget to the bucket at key
+// atomically.
+type Store interface {
+	Take(key string, budget Budget, now time.Time) (Decision, error)
+}
+
+// Limiter decides whether a client may make a request.
+type Limiter struct {
+	Rules Rules
+	Store Store
+	Now   func() time.Time
+}
+
+func NewLimiter(rules Rules, store Store) *Limiter {
+	return &Limiter{Rules: rules, Store: store, Now: time.Now}
+}
+
+// Allow spends one request of the client's budget for the route. The client
+// is any string identifying it, such as "ip:192.0.2.1".
+func (l *Limiter) Allow(client string, method string, route string) (Decision, error) {
+	name, budget := l.Rules.Budget(method, route)
+	return l.Store.Take(name+"|"+client, budget, l.Now())
+}
@@ -0,0 +1,129 @@
+package ratelimit_test
+
+import (
+	"database/sql"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/db"
+	"food-review/pkg/ratelimit"
+)
+
+const testRules = `{
+	"default": {"requests": 60, "per": "1m"},
+	"routes": {
+		"PUT /reviews/{reviewID}": {"requests": 2, "per": "1m", "burst": 1}
+	}
+}`
+
+func stores(t *testing.T) map[string]ratelimit.Store {
+	database, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ratelimit.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	_, err = db.MigrateUp(database, db.RateLimitMigrations)
+	require.NoError(t, err)
+
+	return map[string]ratelimit.Store{
+		"Memory": ratelimit.NewMemoryStore(),
+		"SQLite": ratelimit.NewSQLiteStore(database),
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	rules, err := ratelimit.ParseRules([]byte(testRules))
+	require.NoError(t, err)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			limiter := ratelimit.NewLimiter(rules, store)
+			limiter.Now = func() time.Time { return now }
+
+			decision, err := limiter.Allow("ip:192.0.2.1", "PUT", "/reviews/{reviewID}")
+			require.NoError(t, err)
+			assert.Equal(t, ratelimit.Decision{Allowed: true, Limit: 1, Remaining: 0, Reset: 30 * time.Second}, decision)
+
+			decision, err = limiter.Allow("ip:192.0.2.1", "PUT", "/reviews/{reviewID}")
+			require.NoError(t, err)
+			assert.False(t, decision.Allowed)
+			assert.Equal(t, 30*time.Second, decision.RetryAfter)
+
+			decision, err = limiter.Allow("ip:192.0.2.2", "PUT", "/reviews/{reviewID}")
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed, "other clients have their own bucket")
+
+			decision, err = limiter.Allow("ip:192.0.2.1", "GET", "/reviews")
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed, "other routes have their own budget")
+			assert.Equal(t, 59, decision.Remaining)
+
+			now = now.Add(20 * time.Second)
+			decision, err = limiter.Allow("ip:192.0.2.1", "PUT", "/reviews/{reviewID}")
+			require.NoError(t, err)
+			assert.False(t, decision.Allowed)
+			assert.Equal(t, 10*time.Second, decision.RetryAfter)
+
+			now = now.Add(10 * time.Second)
+			decision, err = limiter.Allow("ip:192.0.2.1", "PUT", "/reviews/{reviewID}")
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+
+			now = now.Add(time.Hour)
+			decision, err = limiter.Allow("ip:192.0.2.1", "PUT", "/reviews/{reviewID}")
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed, "refills no further than the burst")
+			assert.Equal(t, 0, decision.Remaining)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ratelimit.ParseRules([]byte(testRules))
+	if assert.NoError(t, err) {
+		name, budget := rules.Budget("GET", "/reviews/{reviewID}")
+		assert.Equal(t, "default", name)
+		assert.Equal(t, 60, budget.Burst, "burst defaults to requests")
+
+		name, budget = rules.Budget("PUT", "/reviews/{reviewID}")
+		assert.Equal(t, "PUT /reviews/{reviewID}", name)
+		assert.Equal(t, 1, budget.Burst)
+	}
+
+	for name, data := range map[string]string{
+		"No Default":     `{"routes": {}}`,
+		"Bad Period":     `{"default": {"requests": 1, "per": "soon"}}`,
+		"Zero Requests":  `{"default": {"requests": 0, "per": "1m"}}`,
+		"Negative Burst": `{"default": {"requests": 1, "per": "1m"}, "routes": {"GET /": {"requests": 1, "per": "1m", "burst": -1}}}`,
+	} {
+		_, err := ratelimit.ParseRules([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := ratelimit.LoadRules(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("Invalid File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ratelimit.json")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"default": {}}`), 0644))
+
+		_, err := ratelimit.LoadRules(path)
+		assert.ErrorContains(t, err, path)
+	})
+
+	t.Run("No File Uses Defaults", func(t *testing.T) {
+		rules, err := ratelimit.LoadRules("")
+		if assert.NoError(t, err) {
+			assert.Equal(t, ratelimit.DefaultRules(), rules)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often stores forget buckets that have refilled, which
+// are the same as no bucket at all.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in memory, so limits reset when the server
+// restarts and aren't shared between instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]Bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]Bucket{}}
+}
+
+func (s *MemoryStore) Take(key string, budget Budget, now time.Time) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *Bucket
+	if bucket, ok := s.buckets[key]; ok {
+		current = &bucket
+	}
+	bucket, decision := budget.take(current, now)
+	s.buckets[key] = bucket
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for key, bucket := range s.buckets {
+			if !bucket.Full.After(now) {
+				delete(s.buckets, key)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	return decision, nil
+}
+
+// SQLiteStore keeps buckets in the rate_limit_bucket table so limits survive
+// a restart. Takes are serialised, so it suits a single server process.
+type SQLiteStore struct {
+	mu        sync.Mutex
+	db        *sql.DB
+	lastSweep time.Time
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+func (s *SQLiteStore) Take(key string, budget Budget, now time.Time) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current *Bucket
+	var tokens float64
+	var updated, full int64
+	row := s.db.QueryRow(`
+		SELECT tokens, updated_at, full_at
+		FROM rate_limit_bucket
+		WHERE bucket_key = ?
+	`, key)
+	err := row.Scan(&tokens, &updated, &full)
+	if err == nil {
+		current = &Bucket{Tokens: tokens, Updated: time.Unix(0, updated), Full: time.Unix(0, full)}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return Decision{}, err
+	}
+
+	bucket, decision := budget.take(current, now)
+	_, err = s.db.Exec(`
+		INSERT INTO rate_limit_bucket (bucket_key, tokens, updated_at, full_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (bucket_key) DO UPDATE
+		SET tokens = excluded.tokens, updated_at = excluded.updated_at, full_at = excluded.full_at
+	`, key, bucket.Tokens, bucket.Updated.UnixNano(), bucket.Full.UnixNano())
+	if err != nil {
+		return Decision{}, err
+	}
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		_, err = s.db.Exec("DELETE FROM rate_limit_bucket WHERE full_at <= ?", now.UnixNano())
+		if err != nil {
+			return Decision{}, err
+		}
+		s.lastSweep = now
+	}
+
+	return decision, nil
+}
@@ -0,0 +1,120 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"food-review/pkg/extract"
+	"food-review/pkg/logging"
+	"food-review/pkg/store"
+)
+
+// KeywordScan keeps the ranked keyword candidates of the latest scan of the
+// approved reviews, so the candidates page reads them rather than rescanning
+// every review on each visit. The server scans periodically with Run, and a
+// moderator can ask for a scan from the page.
+type KeywordScan struct {
+	Reviews    store.ReviewStore
+	Dictionary store.DictionaryStore
+
+	// scanning keeps a periodic and a requested scan from running at once.
+	scanning   sync.Mutex
+	mu         sync.RWMutex
+	candidates []extract.Candidate
+	scannedAt  time.Time
+}
+
+// Scan ranks the terms of the approved reviews that aren't in the
+// dictionary and keeps them for Latest.
+func (s *KeywordScan) Scan() error {
+	s.scanning.Lock()
+	defer s.scanning.Unlock()
+
+	keywords, err := s.Dictionary.List()
+	if err != nil {
+		return err
+	}
+	candidates, err := s.Reviews.KeywordCandidates(keywords)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.candidates, s.scannedAt = candidates, time.Now().UTC()
+	s.mu.Unlock()
+	return nil
+}
+
+// Latest returns the candidates of the last scan, less those in keywords, and
+// when the scan finished. The time is zero until a scan has finished.
+func (s *KeywordScan) Latest(keywords []string) ([]extract.Candidate, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	added := map[string]bool{}
+	for _, keyword := range keywords {
+		added[keyword] = true
+	}
+	var candidates []extract.Candidate
+	for _, candidate := range s.candidates {
+		if !added[candidate.Term] {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, s.scannedAt
+}
+
+// Run scans straight away and then every interval until ctx is done. A
+// failed scan is logged and the previous candidates kept.
+func (s *KeywordScan) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Scan(); err != nil {
+			logging.Default().Error("keyword scan failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type KeywordCandidatesPage struct {
+	Candidates []extract.Candidate
+	ScannedAt  time.Time
+}
+
+func (h *Handler) GetKeywordCandidates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	keywords, err := h.dictionary(r).List()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	page := KeywordCandidatesPage{}
+	page.Candidates, page.ScannedAt = h.Candidates.Latest(keywords)
+	err = h.executeTemplate(w, r, "keyword_candidates.html", page)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
+
+// ScanKeywordCandidates rescans the reviews for the candidates page now
+// rather than at the next periodic scan.
+func (h *Handler) ScanKeywordCandidates(w http.ResponseWriter, r *http.Request) {
+	if err := h.Candidates.Scan(); err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,36 @@
+package route
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"food-review/pkg/logging"
+	"food-review/pkg/model"
+)
+
+func (h *Handler) AddKeyword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	keywordBody, _ := ioutil.ReadAll(r.Body)
+	keyword, err := h.dictionary(r).Add(keywordBody)
+	if err == model.ErrEmptyKeyword {
+		clientError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if err == model.ErrKeywordExists {
+		clientError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	// Like refreshMentions, a failure to index is only logged; the keyword
+	// is in the dictionary either way.
+	if err := h.reviews(r).IndexKeyword(keyword); err != nil {
+		logging.FromContext(r.Context()).Error("index keyword", "keyword", keyword, "error", err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"keyword": keyword})
+}
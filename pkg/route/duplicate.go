@@ -0,0 +1,45 @@
+package route
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"food-review/pkg/model"
+)
+
+func (h *Handler) GetDuplicateClusters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	clusters, err := h.reviews(r).DuplicateClusters()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = h.executeTemplate(w, r, "duplicates.html", clusters)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) MergeReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	mergeBody, _ := ioutil.ReadAll(r.Body)
+	keptID, err := h.reviews(r).Merge(ModeratorFromContext(r.Context()), mergeBody)
+	if err == model.ErrMergeTooFew {
+		clientError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if err == sql.ErrNoRows {
+		clientError(w, r, http.StatusUnprocessableEntity, "No Review with this ID")
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]uint{"review_id": keptID})
+}
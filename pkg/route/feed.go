@@ -0,0 +1,146 @@
+package route
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"food-review/pkg/feed"
+	"food-review/pkg/i18n"
+	"food-review/pkg/logging"
+	"food-review/pkg/model"
+)
+
+const (
+	// feedSize is how many of the latest reviews a feed carries. Readers
+	// poll, so older entries have been seen already.
+	feedSize = 50
+	// feedTitleLength is how much of a review, in runes, titles its entry.
+	feedTitleLength = 60
+)
+
+// GetReviewsAtom is the Atom feed of new and edited reviews.
+func (h *Handler) GetReviewsAtom(w http.ResponseWriter, r *http.Request) {
+	h.reviewsFeed(w, r, (*feed.Feed).WriteAtom, feed.AtomContentType)
+}
+
+// GetReviewsRSS is GetReviewsAtom for readers that only speak RSS.
+func (h *Handler) GetReviewsRSS(w http.ResponseWriter, r *http.Request) {
+	h.reviewsFeed(w, r, (*feed.Feed).WriteRSS, feed.RSSContentType)
+}
+
+func (h *Handler) reviewsFeed(w http.ResponseWriter, r *http.Request, write func(*feed.Feed, io.Writer) error, contentType string) {
+	reviews, err := h.reviews(r).List()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	site := siteURL(r)
+	f := h.newFeed(r, reviews)
+	f.Title = translate(r, "New reviews")
+	f.Link = site + "/reviews"
+	f.ID = site + r.URL.Path
+	f.Self = f.ID
+
+	h.writeFeed(w, r, f, write, contentType)
+}
+
+// GetSearchAtom is the Atom feed of reviews mentioning the query keyword,
+// the saved-search counterpart of GetReviewsByKeyword. A keyword nobody has
+// mentioned yet gives an empty feed, so it can be subscribed to ahead.
+func (h *Handler) GetSearchAtom(w http.ResponseWriter, r *http.Request) {
+	reviews, ok := h.searchReviews(w, r)
+	if !ok {
+		return
+	}
+
+	query := url.Values{"query": {r.URL.Query().Get("query")}}
+	if sentimentLabel := r.URL.Query().Get("sentiment"); sentimentLabel != "" {
+		query.Set("sentiment", sentimentLabel)
+	}
+
+	site := siteURL(r)
+	f := h.newFeed(r, reviews)
+	f.Title = translate(r, "Reviews of %s", query.Get("query"))
+	f.Link = site + "/reviews?" + query.Encode()
+	f.ID = site + r.URL.Path + "?" + query.Encode()
+	f.Self = f.ID
+
+	h.writeFeed(w, r, f, (*feed.Feed).WriteAtom, feed.AtomContentType)
+}
+
+// newFeed makes a feed of the latest updated reviews, with their Markdown
+// rendered as on the review pages.
+func (h *Handler) newFeed(r *http.Request, reviews []*model.Review) *feed.Feed {
+	sorted := make([]*model.Review, len(reviews))
+	copy(sorted, reviews)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].UpdatedAt.Equal(sorted[j].UpdatedAt) {
+			return sorted[i].UpdatedAt.After(sorted[j].UpdatedAt)
+		}
+		return sorted[i].ID > sorted[j].ID
+	})
+	if len(sorted) > feedSize {
+		sorted = sorted[:feedSize]
+	}
+
+	site := siteURL(r)
+	f := &feed.Feed{
+		Base:   site + "/",
+		Author: translate(r, "Food review"),
+	}
+	for _, review := range sorted {
+		link := site + "/reviews/" + strconv.FormatUint(uint64(review.ID), 10)
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:        link,
+			Title:     h.entryTitle(review.Content),
+			Link:      link,
+			Published: review.CreatedAt,
+			Updated:   review.UpdatedAt,
+			Content:   h.Markdown.Render(review.Content),
+		})
+	}
+	return f
+}
+
+// translate is message in the request's language.
+func translate(r *http.Request, message string, args ...interface{}) string {
+	return i18n.FromContext(r.Context()).T(message, args...)
+}
+
+// entryTitle is the start of a review's first line, masked like the review
+// itself. Titles are plain text, so the Markdown is left as typed.
+func (h *Handler) entryTitle(content string) string {
+	title := strings.TrimSpace(content)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	if utf8.RuneCountInString(title) > feedTitleLength {
+		runes := []rune(title)
+		title = strings.TrimSpace(string(runes[:feedTitleLength])) + "…"
+	}
+	return h.Profanity.Render(title)
+}
+
+func (h *Handler) writeFeed(w http.ResponseWriter, r *http.Request, f *feed.Feed, write func(*feed.Feed, io.Writer) error, contentType string) {
+	w.Header().Set("Content-Type", contentType)
+	if err := write(f, w); err != nil {
+		logging.FromContext(r.Context()).Error("writing feed failed", "error", err)
+	}
+}
+
+// siteURL is the scheme and host the request was made to, for the absolute
+// links feeds need. A proxy terminating TLS is trusted to say so in
+// X-Forwarded-Proto.
+func siteURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"food-review/pkg/version"
+)
+
+// ReadinessCheck returns an error while the thing it checks can't serve
+// requests.
+type ReadinessCheck func() error
+
+// HealthHandler serves the orchestrator's probes. It is separate from Handler
+// so the probes keep working whatever the review handlers depend on.
+type HealthHandler struct {
+	// Checks are run by Readyz and reported under their names.
+	Checks  map[string]ReadinessCheck
+	Version version.Info
+}
+
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Healthz only shows that the process is up and serving.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, "ok")
+}
+
+// Readyz runs every check and answers 503 if any fails.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	result := readiness{Status: "ready", Checks: map[string]string{}}
+	status := http.StatusOK
+
+	for name, check := range h.Checks {
+		if err := check(); err != nil {
+			result.Checks[name] = err.Error()
+			result.Status = "not ready"
+			status = http.StatusServiceUnavailable
+		} else {
+			result.Checks[name] = "ok"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *HealthHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Version)
+}
@@ -0,0 +1,99 @@
+package route
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+
+	"food-review/pkg/model"
+)
+
+type moderatorKey struct{}
+
+// WithModerator returns a copy of ctx naming the moderator who made the
+// request, as the server's authentication sets it.
+func WithModerator(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, moderatorKey{}, name)
+}
+
+// ModeratorFromContext is the moderator who made the request, or "".
+func ModeratorFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(moderatorKey{}).(string)
+	return name
+}
+
+func (h *Handler) FlagReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	reviewID, err := parseReviewID(r)
+	if err != nil {
+		clientError(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	flagBody, _ := ioutil.ReadAll(r.Body)
+	err = h.reviews(r).Flag(reviewID, flagBody)
+	if err == sql.ErrNoRows {
+		clientError(w, r, http.StatusUnprocessableEntity, "No Review with this ID")
+		return
+	} else if err == model.ErrInvalidFlagReason {
+		clientError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	queue, err := h.reviews(r).ModerationQueue()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = h.executeTemplate(w, r, "moderation.html", queue)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.reviews(r).Approve)
+}
+
+func (h *Handler) RejectReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.reviews(r).Reject)
+}
+
+func (h *Handler) AnnotateReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, h.reviews(r).Annotate)
+}
+
+type moderationAction func(reviewID uint, moderator string, noteBody []byte) error
+
+func (h *Handler) moderate(w http.ResponseWriter, r *http.Request, action moderationAction) {
+	w.Header().Set("Content-Type", "text/html")
+
+	reviewID, err := parseReviewID(r)
+	if err != nil {
+		clientError(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	noteBody, _ := ioutil.ReadAll(r.Body)
+	err = action(reviewID, ModeratorFromContext(r.Context()), noteBody)
+	if err == sql.ErrNoRows {
+		clientError(w, r, http.StatusUnprocessableEntity, "No Review with this ID")
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
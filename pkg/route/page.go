@@ -0,0 +1,162 @@
+package route
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"food-review/pkg/model"
+)
+
+const reviewsPerPage = 20
+
+// ReviewPage is what the review list templates render: one page of reviews
+// and, for a search, what was searched for.
+type ReviewPage struct {
+	Reviews    []*model.Review
+	Keyword    string
+	Sentiment  string
+	Filter     ReviewFilter
+	Pagination Pagination
+}
+
+// Sort orders for ReviewFilter. Without one, reviews keep the order the
+// store lists them in.
+const (
+	SortNewest  = "newest"
+	SortOldest  = "oldest"
+	SortUpdated = "updated"
+)
+
+// dayLayout is how the from and to parameters, and date inputs, write a day.
+const dayLayout = "2006-01-02"
+
+// ReviewFilter narrows a review list to those posted between From and To,
+// inclusive days in UTC written as "2006-01-02", and sorts it. Empty fields
+// don't filter.
+type ReviewFilter struct {
+	From string
+	To   string
+	Sort string
+}
+
+// parseFilter reads the from, to and sort query parameters. On bad input it
+// returns the message to answer 400 with.
+func parseFilter(r *http.Request) (ReviewFilter, string) {
+	query := r.URL.Query()
+	filter := ReviewFilter{From: query.Get("from"), To: query.Get("to"), Sort: query.Get("sort")}
+
+	from, err := parseDay(filter.From)
+	if err != nil {
+		return filter, "Invalid date"
+	}
+	to, err := parseDay(filter.To)
+	if err != nil {
+		return filter, "Invalid date"
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return filter, "Invalid date range"
+	}
+
+	switch filter.Sort {
+	case "", SortNewest, SortOldest, SortUpdated:
+	default:
+		return filter, "Invalid sort"
+	}
+	return filter, ""
+}
+
+func parseDay(day string) (time.Time, error) {
+	if day == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dayLayout, day)
+}
+
+// apply returns the reviews posted in the filter's range, sorted. The
+// filter must have come from parseFilter.
+func (f ReviewFilter) apply(reviews []*model.Review) []*model.Review {
+	from, _ := parseDay(f.From)
+	to, _ := parseDay(f.To)
+
+	var filtered []*model.Review
+	for _, review := range reviews {
+		if !from.IsZero() && review.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !review.CreatedAt.Before(to.AddDate(0, 0, 1)) {
+			continue
+		}
+		filtered = append(filtered, review)
+	}
+
+	switch f.Sort {
+	case SortNewest:
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].CreatedAt.After(filtered[j].CreatedAt) })
+	case SortOldest:
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].CreatedAt.Before(filtered[j].CreatedAt) })
+	case SortUpdated:
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].UpdatedAt.After(filtered[j].UpdatedAt) })
+	}
+	return filtered
+}
+
+// Pagination links a page of results to its neighbours. The URLs are empty
+// at either end.
+type Pagination struct {
+	Page    int
+	Pages   int
+	PrevURL string
+	NextURL string
+}
+
+// parsePage reads the 1-based page query parameter, defaulting to the first.
+func parsePage(r *http.Request) (int, bool) {
+	pageStr := r.URL.Query().Get("page")
+	if pageStr == "" {
+		return 1, true
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		return 0, false
+	}
+	return page, true
+}
+
+// paginate returns the reviews on page, which may be past the end, and the
+// links to the pages around it.
+func paginate(r *http.Request, reviews []*model.Review, page int) ([]*model.Review, Pagination) {
+	pages := (len(reviews) + reviewsPerPage - 1) / reviewsPerPage
+	if pages == 0 {
+		pages = 1
+	}
+	pagination := Pagination{Page: page, Pages: pages}
+
+	pageURL := func(n int) string {
+		query := r.URL.Query()
+		if n == 1 {
+			query.Del("page")
+		} else {
+			query.Set("page", strconv.Itoa(n))
+		}
+		return (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+	}
+	if page > 1 && page <= pages {
+		pagination.PrevURL = pageURL(page - 1)
+	}
+	if page < pages {
+		pagination.NextURL = pageURL(page + 1)
+	}
+
+	start := (page - 1) * reviewsPerPage
+	if start >= len(reviews) {
+		return nil, pagination
+	}
+	end := start + reviewsPerPage
+	if end > len(reviews) {
+		end = len(reviews)
+	}
+	return reviews[start:end], pagination
+}
@@ -0,0 +1,33 @@
+package route
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"food-review/pkg/model"
+)
+
+// maxPreviewBytes bounds a preview request. Previews are sent as the
+// reviewer types, so they're kept cheap.
+const maxPreviewBytes = 64 << 10
+
+// PreviewReview renders the Markdown of a review being written, the same way
+// it will show once saved, for the live preview on the edit page. Nothing is
+// stored.
+func (h *Handler) PreviewReview(w http.ResponseWriter, r *http.Request) {
+	reviewBody, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPreviewBytes))
+	if err != nil {
+		clientError(w, r, http.StatusRequestEntityTooLarge, "Review is too long to preview")
+		return
+	}
+
+	review := model.Review{}
+	if err := json.Unmarshal(reviewBody, &review); err != nil {
+		clientError(w, r, http.StatusBadRequest, "Invalid review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(h.Markdown.Render(review.Content)))
+}
@@ -0,0 +1,31 @@
+package route
+
+import (
+	"encoding/json"
+
+	"food-review/pkg/model"
+	"food-review/pkg/profanity"
+)
+
+// screenProfanity returns the flags to raise on a submitted review body for
+// the profane words in it, and profanity.ErrProfanity when the filter is set
+// to reject them. Masking happens at render time, so in that mode nothing is
+// flagged.
+func (h *Handler) screenProfanity(reviewBody []byte) ([]model.Flag, error) {
+	if h.Profanity == nil || h.Profanity.Mode == profanity.ModeMask {
+		return nil, nil
+	}
+
+	review := model.Review{}
+	if err := json.Unmarshal(reviewBody, &review); err != nil {
+		// The model reports malformed bodies.
+		return nil, nil
+	}
+
+	words := h.Profanity.Words(review.Content)
+	if len(words) > 0 && h.Profanity.Mode == profanity.ModeReject {
+		return nil, profanity.ErrProfanity
+	}
+
+	return model.ProfanityFlags(words), nil
+}
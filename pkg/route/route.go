@@ -2,22 +2,124 @@ package route
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
-	"food-review/pkg/db"
+	"food-review/pkg/i18n"
+	"food-review/pkg/logging"
+	"food-review/pkg/markdown"
 	"food-review/pkg/model"
+	"food-review/pkg/profanity"
+	"food-review/pkg/spam"
+	"food-review/pkg/store"
 	"food-review/pkg/template"
+	"food-review/pkg/tracing"
 )
 
 type Handler struct {
-	Template     template.Templater
-	ReviewDB     db.ReviewDBOpener
-	DictionaryDB db.DictionaryDBOpener
+	Template   template.Templater
+	Reviews    store.ReviewStore
+	Dictionary store.DictionaryStore
+	// Spam screens submitted reviews; nil disables the checks.
+	Spam *spam.Checker
+	// Profanity rejects, flags or masks profane reviews; nil disables it.
+	Profanity *profanity.Filter
+	// Markdown renders review previews like the templates render reviews.
+	Markdown *markdown.Renderer
+	// Candidates holds the keyword candidates the admin page lists.
+	Candidates *KeywordScan
+}
+
+func parseReviewID(r *http.Request) (uint, error) {
+	reviewIDstr := mux.Vars(r)["reviewID"]
+	reviewIDu64, err := strconv.ParseUint(reviewIDstr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(reviewIDu64), nil
+}
+
+// clientIP is the address a request came from, used as the submitter for the
+// spam checker's rate rule.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// reviews is h.Reviews with every call traced and logged at debug level
+// against the request, so slow or failing queries can be traced back to it.
+func (h *Handler) reviews(r *http.Request) store.ReviewStore {
+	return store.NewObservedReviewStore(h.Reviews, observeStoreCalls(r))
+}
+
+func (h *Handler) dictionary(r *http.Request) store.DictionaryStore {
+	return store.NewObservedDictionaryStore(h.Dictionary, observeStoreCalls(r))
+}
+
+// observeStoreCalls gives each store call, and so the model function and SQL
+// queries behind it, a span under the request's and a debug log line.
+func observeStoreCalls(r *http.Request) store.Observer {
+	logger := logging.FromContext(r.Context())
+	return func(storeName string, operation string) func(err error) {
+		start := time.Now()
+		_, span := tracing.Start(r.Context(), storeName+"."+operation)
+		span.SetAttributes("store", storeName, "operation", operation)
+		return func(err error) {
+			span.RecordError(err)
+			span.End()
+
+			args := []interface{}{
+				"store", storeName,
+				"operation", operation,
+				"duration_ms", milliseconds(time.Since(start)),
+			}
+			if err != nil {
+				args = append(args, "error", err)
+			}
+			logger.Debug("store call", args...)
+		}
+	}
+}
+
+// executeTemplate renders the named template in a span of its own.
+func (h *Handler) executeTemplate(w http.ResponseWriter, r *http.Request, name string, data interface{}) error {
+	_, span := tracing.Start(r.Context(), "template "+name)
+	span.SetAttributes("template", name)
+	defer span.End()
+
+	err := h.Template.ExecuteTemplate(w, name, data)
+	span.RecordError(err)
+	return err
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// serverError answers 500 with err and logs it with the request's ID, so the
+// failing query can be matched to the request that ran it.
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).Error("request failed", "error", err)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(err.Error()))
+}
+
+// clientError answers status with message, translated into the request's
+// language. message is the English text, which is also the catalog key.
+func clientError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.WriteHeader(status)
+	w.Write([]byte(i18n.FromContext(r.Context()).T(message)))
 }
 
 func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
@@ -27,83 +129,140 @@ func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetAllReviews(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
-	db := h.ReviewDB.GetDB()
-	allReviews, err := model.GetAllReviews(db)
+	allReviews, err := h.reviews(r).List()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverError(w, r, err)
+		return
+	}
+
+	filter, invalid := parseFilter(r)
+	if invalid != "" {
+		clientError(w, r, http.StatusBadRequest, invalid)
+		return
+	}
+
+	page, ok := parsePage(r)
+	if !ok {
+		clientError(w, r, http.StatusBadRequest, "Invalid page")
 		return
 	}
+	reviews, pagination := paginate(r, filter.apply(allReviews), page)
 
-	err = h.Template.ExecuteTemplate(w, "reviews.html", allReviews)
+	err = h.executeTemplate(w, r, "reviews.html", ReviewPage{Reviews: reviews, Filter: filter, Pagination: pagination})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverError(w, r, err)
 		return
 	}
 }
 
+// GetReview is the page of one review. Reviews waiting for or refused by a
+// moderator aren't public, so they are not found.
+// publishedReview fetches a review readers may see or edit. Pending and
+// rejected reviews get a 404, as if they didn't exist, so they can't be read
+// or edited back into the queue. When there is no such review or it isn't
+// approved, it answers the request itself and ok is false.
+func (h *Handler) publishedReview(w http.ResponseWriter, r *http.Request, reviewID uint) (review *model.Review, ok bool) {
+	review, err := h.reviews(r).Get(reviewID)
+	if err != nil {
+		clientError(w, r, http.StatusUnprocessableEntity, "No Review with this ID")
+		return nil, false
+	}
+	if review.Status != model.StatusApproved {
+		clientError(w, r, http.StatusNotFound, "No Review with this ID")
+		return nil, false
+	}
+
+	return review, true
+}
+
 func (h *Handler) GetReview(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
 	reviewIDstr := mux.Vars(r)["reviewID"]
 	reviewIDu64, err := strconv.ParseUint(reviewIDstr, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Invalid ID"))
+		clientError(w, r, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
-	db := h.ReviewDB.GetDB()
-	targetReview, err := model.GetReview(db, uint(reviewIDu64))
-	if err != nil {
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		w.Write([]byte("No Review with this ID"))
+	targetReview, ok := h.publishedReview(w, r, uint(reviewIDu64))
+	if !ok {
 		return
 	}
 
-	err = h.Template.ExecuteTemplate(w, "review.html", targetReview)
+	err = h.executeTemplate(w, r, "review.html", targetReview)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverError(w, r, err)
 		return
 	}
 }
 
-func (h *Handler) GetReviewsByKeyword(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html")
-
+// searchReviews finds the approved reviews mentioning the query keyword,
+// narrowed to the sentiment parameter if there is one. When the keyword isn't
+// in the dictionary, the sentiment is invalid or the store fails, it answers
+// the request itself and ok is false. No matches is not an error.
+func (h *Handler) searchReviews(w http.ResponseWriter, r *http.Request) (reviews []*model.Review, ok bool) {
 	reviewKeyword := r.URL.Query().Get("query")
-	dict := h.DictionaryDB.GetDB()
-	exist, err := model.KeywordExists(dict, reviewKeyword)
+	exist, err := h.dictionary(r).KeywordExists(reviewKeyword)
 	if !exist {
 		if err == nil {
-			w.WriteHeader(http.StatusUnprocessableEntity)
-			w.Write([]byte("Keyword not in dictionary"))
+			clientError(w, r, http.StatusUnprocessableEntity, "Keyword not in dictionary")
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(err.Error()))
+			serverError(w, r, err)
 		}
 
+		return nil, false
+	}
+
+	sentimentLabel := r.URL.Query().Get("sentiment")
+	reviews, err = h.reviews(r).Search(reviewKeyword, sentimentLabel)
+	if err == model.ErrInvalidSentiment {
+		clientError(w, r, http.StatusBadRequest, err.Error())
+		return nil, false
+	} else if err == sql.ErrNoRows {
+		return nil, true
+	} else if err != nil {
+		serverError(w, r, err)
+		return nil, false
+	}
+
+	return reviews, true
+}
+
+func (h *Handler) GetReviewsByKeyword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	targetReviews, ok := h.searchReviews(w, r)
+	if !ok {
+		return
+	}
+	if len(targetReviews) == 0 {
+		clientError(w, r, http.StatusUnprocessableEntity, "No review you are looking for")
 		return
 	}
 
-	db := h.ReviewDB.GetDB()
-	targetReviews, err := model.GetReviewsByKeyword(db, reviewKeyword)
-	if err == sql.ErrNoRows {
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		w.Write([]byte("No review you are looking for"))
+	filter, invalid := parseFilter(r)
+	if invalid != "" {
+		clientError(w, r, http.StatusBadRequest, invalid)
 		return
-	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	}
+
+	page, ok := parsePage(r)
+	if !ok {
+		clientError(w, r, http.StatusBadRequest, "Invalid page")
 		return
 	}
+	reviews, pagination := paginate(r, filter.apply(targetReviews), page)
 
-	err = h.Template.ExecuteTemplate(w, "reviews_keyword.html", targetReviews)
+	err := h.executeTemplate(w, r, "reviews_keyword.html", ReviewPage{
+		Reviews:    reviews,
+		Keyword:    r.URL.Query().Get("query"),
+		Sentiment:  r.URL.Query().Get("sentiment"),
+		Filter:     filter,
+		Pagination: pagination,
+	})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverError(w, r, err)
 		return
 	}
 }
@@ -114,23 +273,18 @@ func (h *Handler) AccessReviewEdit(w http.ResponseWriter, r *http.Request) {
 	reviewIDstr := mux.Vars(r)["reviewID"]
 	reviewIDu64, err := strconv.ParseUint(reviewIDstr, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Invalid ID"))
+		clientError(w, r, http.StatusBadRequest, "Invalid ID")
 		return
 	}
 
-	db := h.ReviewDB.GetDB()
-	targetReview, err := model.GetReview(db, uint(reviewIDu64))
-	if err != nil {
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		w.Write([]byte("No Review with this ID"))
+	targetReview, ok := h.publishedReview(w, r, uint(reviewIDu64))
+	if !ok {
 		return
 	}
 
-	err = h.Template.ExecuteTemplate(w, "edit.html", targetReview)
+	err = h.executeTemplate(w, r, "edit.html", targetReview)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		serverError(w, r, err)
 		return
 	}
 }
@@ -141,17 +295,93 @@ func (h *Handler) EditReview(w http.ResponseWriter, r *http.Request) {
 	reviewIDstr := mux.Vars(r)["reviewID"]
 	reviewIDu64, err := strconv.ParseUint(reviewIDstr, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Invalid ID"))
+		clientError(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if _, ok := h.publishedReview(w, r, uint(reviewIDu64)); !ok {
 		return
 	}
 
 	reviewBody, _ := ioutil.ReadAll(r.Body)
-	db := h.ReviewDB.GetDB()
-	err = model.UpdateReview(db, uint(reviewIDu64), reviewBody)
+	profanityFlags, err := h.screenProfanity(reviewBody)
+	if err != nil {
+		clientError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	spamFlags, err := h.screenSpam(r, reviewBody)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		clientError(w, r, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
+
+	err = h.reviews(r).Update(uint(reviewIDu64), reviewBody, append(spamFlags, profanityFlags...))
+	if err == sql.ErrNoRows {
+		clientError(w, r, http.StatusUnprocessableEntity, "No Review with this ID")
+		return
+	} else if err == model.ErrDuplicateReview {
+		clientError(w, r, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	h.refreshMentions(r, uint(reviewIDu64))
+}
+
+func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	reviewBody, _ := ioutil.ReadAll(r.Body)
+	profanityFlags, err := h.screenProfanity(reviewBody)
+	if err != nil {
+		clientError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	spamFlags, err := h.screenSpam(r, reviewBody)
+	if err != nil {
+		clientError(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	newReview, err := h.reviews(r).Create(reviewBody, append(spamFlags, profanityFlags...))
+	if err == model.ErrEmptyReview {
+		clientError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if err == model.ErrDuplicateReview {
+		clientError(w, r, http.StatusConflict, err.Error())
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	h.refreshMentions(r, newReview.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newReview)
+}
+
+func (h *Handler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	reviewID, err := parseReviewID(r)
+	if err != nil {
+		clientError(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	err = h.reviews(r).Delete(reviewID)
+	if err == sql.ErrNoRows {
+		clientError(w, r, http.StatusUnprocessableEntity, "No Review with this ID")
+		return
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
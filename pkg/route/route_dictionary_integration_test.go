@@ -0,0 +1,129 @@
+package route_test
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+
+	"food-review/pkg/route"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeywordCandidatesIntegrationService(t *testing.T) {
+	url := "/admin/keywords"
+
+	dbDict, mockDict, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	dbRev, mockRev, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+
+	listStatement := "SELECT keyword FROM dictionary ORDER BY keyword"
+	mockTmpl := &mockTemplate{errMsg: nil}
+	mockHandler := constructHandler(mockTmpl, &mockReviewDB{Database: dbRev}, &mockDictionaryDB{Database: dbDict})
+
+	t.Run("Not Scanned", func(t *testing.T) {
+		mockDict.ExpectQuery(listStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("pad thai"))
+
+		testHandler(t, mockHandler.GetKeywordCandidates, GET, url, nil, nil, http.StatusOK)
+
+		page := mockTmpl.data.(route.KeywordCandidatesPage)
+		assert.True(t, page.ScannedAt.IsZero())
+		assert.Empty(t, page.Candidates)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		mockDict.ExpectQuery(listStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("pad thai"))
+		mockRev.ExpectQuery("SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 ORDER BY review_id").
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow("1", "Pad thai and boat noodles", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0))
+
+		testHandler(t, mockHandler.ScanKeywordCandidates, POST, url+"/scan", nil, nil, http.StatusNoContent)
+	})
+
+	t.Run("Reads the Stored Scan", func(t *testing.T) {
+		// Only the dictionary is read; the reviews aren't scanned again.
+		mockDict.ExpectQuery(listStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("pad thai"))
+
+		testHandler(t, mockHandler.GetKeywordCandidates, GET, url, nil, nil, http.StatusOK)
+
+		page := mockTmpl.data.(route.KeywordCandidatesPage)
+		assert.False(t, page.ScannedAt.IsZero())
+		assert.NoError(t, mockRev.ExpectationsWereMet())
+	})
+
+	t.Run("Scan Error", func(t *testing.T) {
+		mockDict.ExpectQuery(listStatement).
+			WillReturnError(sql.ErrConnDone)
+
+		testHandler(t, mockHandler.ScanKeywordCandidates, POST, url+"/scan", nil, nil, http.StatusInternalServerError)
+	})
+}
+
+func TestAddKeywordIntegrationService(t *testing.T) {
+	url := "/admin/keywords"
+
+	dbDict, mockDict, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	dbRev, mockRev, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	existStatement := "SELECT keyword FROM dictionary WHERE keyword = $1"
+
+	t.Run("Empty Keyword", func(t *testing.T) {
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mockHandler := constructHandler(nil, nil, mockDictDB)
+
+		body := strings.NewReader(`{"keyword": ""}`)
+		testHandler(t, mockHandler.AddKeyword, POST, url, body, nil, http.StatusBadRequest)
+	})
+
+	t.Run("Already in Dictionary", func(t *testing.T) {
+		mockDict.ExpectQuery(existStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("boat noodles"))
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mockHandler := constructHandler(nil, nil, mockDictDB)
+
+		body := strings.NewReader(`{"keyword": "boat noodles"}`)
+		testHandler(t, mockHandler.AddKeyword, POST, url, body, nil, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mockDict.ExpectQuery(existStatement).
+			WillReturnError(sql.ErrNoRows)
+		mockDict.ExpectExec("INSERT INTO dictionary (keyword) VALUES ($1)").
+			WithArgs("boat noodles").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mockRev.ExpectQuery("SELECT review_id, review FROM review WHERE review LIKE '%' || $1 || '%'").
+			WithArgs("boat noodles").
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review"}).
+				AddRow("2", "Boat noodles, then more boat noodles"))
+		mockRev.ExpectBegin()
+		mockRev.ExpectExec(`INSERT INTO keyword_mention (review_id, keyword, mentions) VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, keyword) DO UPDATE SET mentions = excluded.mentions`).
+			WithArgs(uint(2), "boat noodles", 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockRev.ExpectCommit()
+		mockRevDB := &mockReviewDB{Database: dbRev}
+		mockHandler := constructHandler(nil, mockRevDB, mockDictDB)
+
+		body := strings.NewReader(`{"keyword": "boat noodles"}`)
+		testHandler(t, mockHandler.AddKeyword, POST, url, body, nil, http.StatusCreated)
+		if err := mockRev.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
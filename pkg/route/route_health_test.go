@@ -0,0 +1,61 @@
+package route_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/route"
+	"food-review/pkg/version"
+)
+
+func TestHealthHandler(t *testing.T) {
+	health := &route.HealthHandler{
+		Checks: map[string]route.ReadinessCheck{
+			"review_db": func() error { return nil },
+		},
+		Version: version.Get(map[string]int{"review": 5}),
+	}
+
+	t.Run("Healthz", func(t *testing.T) {
+		testHandler(t, health.Healthz, GET, "/healthz", nil, nil, http.StatusOK)
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		testHandler(t, health.Readyz, GET, "/readyz", nil, nil, http.StatusOK)
+	})
+
+	t.Run("Not Ready", func(t *testing.T) {
+		health.Checks["templates"] = func() error { return errors.New("templates not loaded") }
+		defer delete(health.Checks, "templates")
+
+		w := httptest.NewRecorder()
+		health.Readyz(w, httptest.NewRequest(GET, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var body struct {
+			Status string            `json:"status"`
+			Checks map[string]string `json:"checks"`
+		}
+		if assert.NoError(t, json.NewDecoder(w.Body).Decode(&body)) {
+			assert.Equal(t, "not ready", body.Status)
+			assert.Equal(t, map[string]string{"review_db": "ok", "templates": "templates not loaded"}, body.Checks)
+		}
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		health.GetVersion(w, httptest.NewRequest(GET, "/version", nil))
+
+		var info version.Info
+		if assert.NoError(t, json.NewDecoder(w.Body).Decode(&info)) {
+			assert.NotEmpty(t, info.Commit)
+			assert.NotEmpty(t, info.GoVersion)
+			assert.Equal(t, map[string]int{"review": 5}, info.Schema)
+		}
+	})
+}
@@ -12,7 +12,9 @@ import (
 
 	"sync"
 
+	"food-review/pkg/markdown"
 	"food-review/pkg/route"
+	"food-review/pkg/store"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gorilla/mux"
@@ -24,11 +26,17 @@ const (
 	PUT string = http.MethodPut
 )
 
+// reviewedAt fills the created_at and updated_at columns of mocked reviews.
+var reviewedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
 type mockTemplate struct {
 	errMsg error
+	// data is what the last template was executed with.
+	data interface{}
 }
 
 func (mt *mockTemplate) ExecuteTemplate(w http.ResponseWriter, template string, data interface{}) error {
+	mt.data = data
 	return mt.errMsg
 }
 
@@ -53,11 +61,16 @@ func constructHandler(
 	reviewDBMock *mockReviewDB,
 	dictDBMock *mockDictionaryDB,
 ) *route.Handler {
-	return &route.Handler{
-		Template:     templateMock,
-		ReviewDB:     reviewDBMock,
-		DictionaryDB: dictDBMock,
+	handler := &route.Handler{Template: templateMock}
+	if reviewDBMock != nil {
+		handler.Reviews = store.NewSQLiteReviewStore(reviewDBMock.GetDB())
+	}
+	if dictDBMock != nil {
+		handler.Dictionary = store.NewSQLiteDictionaryStore(dictDBMock.GetDB())
 	}
+	handler.Candidates = &route.KeywordScan{Reviews: handler.Reviews, Dictionary: handler.Dictionary}
+
+	return handler
 }
 
 func testHandler(
@@ -87,9 +100,7 @@ func testHandler(
 	}
 }
 
-func reviewStatement(keyword string) string {
-	return "SELECT review_id, review FROM review WHERE review LIKE '%" + keyword + "%'"
-}
+const keywordStatement = "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 AND review LIKE '%' || $2 || '%' ORDER BY review_id"
 
 func TestGetAllReviewsIntegrationService(t *testing.T) {
 	url := "/reviews"
@@ -98,12 +109,12 @@ func TestGetAllReviewsIntegrationService(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error not expected while opening mock db, %v", err)
 	}
-	statement := "SELECT review_id, review FROM review"
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 ORDER BY review_id"
 
 	t.Run("No Review in Database", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: nil}
 
-		statement := "SELECT review_id, review FROM review"
+		statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE status = $1 ORDER BY review_id"
 		mock.ExpectQuery(statement).WillReturnError(sql.ErrNoRows)
 		mockRDB := &mockReviewDB{Database: mockDB}
 
@@ -115,8 +126,8 @@ func TestGetAllReviewsIntegrationService(t *testing.T) {
 	t.Run("Error Caused in Template", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: errors.New("Some template error")}
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("999999", "Integration review")
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("999999", "Integration review", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
 		mock.ExpectQuery(statement).
 			WillReturnRows(mockRow)
 		mockRDB := &mockReviewDB{Database: mockDB}
@@ -129,8 +140,8 @@ func TestGetAllReviewsIntegrationService(t *testing.T) {
 	t.Run("Happy Path", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: nil}
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("999999", "Integration review")
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("999999", "Integration review", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
 		mock.ExpectQuery(statement).
 			WillReturnRows(mockRow)
 		mockRDB := &mockReviewDB{Database: mockDB}
@@ -139,6 +150,43 @@ func TestGetAllReviewsIntegrationService(t *testing.T) {
 
 		testHandler(t, mockHandler.GetAllReviews, GET, url, nil, nil, http.StatusOK)
 	})
+
+	t.Run("Invalid Page", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("999999", "Integration review", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
+		mock.ExpectQuery(statement).
+			WillReturnRows(mockRow)
+		mockRDB := &mockReviewDB{Database: mockDB}
+
+		mockHandler := constructHandler(mockTmpl, mockRDB, nil)
+
+		testHandler(t, mockHandler.GetAllReviews, GET, url+"?page=zero", nil, nil, http.StatusBadRequest)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"})
+		for i := 1; i <= 45; i++ {
+			mockRow.AddRow(i, "Integration review", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
+		}
+		mock.ExpectQuery(statement).
+			WillReturnRows(mockRow)
+		mockRDB := &mockReviewDB{Database: mockDB}
+
+		mockHandler := constructHandler(mockTmpl, mockRDB, nil)
+
+		testHandler(t, mockHandler.GetAllReviews, GET, url+"?page=2", nil, nil, http.StatusOK)
+
+		page, ok := mockTmpl.data.(route.ReviewPage)
+		if assert.True(t, ok) {
+			assert.Len(t, page.Reviews, 20)
+			assert.Equal(t, uint(21), page.Reviews[0].ID)
+			assert.Equal(t, route.Pagination{Page: 2, Pages: 3, PrevURL: "/reviews", NextURL: "/reviews?page=3"}, page.Pagination)
+		}
+	})
 }
 
 func TestGetReviewIntegrationService(t *testing.T) {
@@ -148,7 +196,7 @@ func TestGetReviewIntegrationService(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error not expected while opening mock db, %v", err)
 	}
-	statement := "SELECT review_id, review FROM review WHERE review_id = ?"
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1"
 
 	t.Run("Invalid ID", func(t *testing.T) {
 		testSuite := []string{
@@ -187,8 +235,8 @@ func TestGetReviewIntegrationService(t *testing.T) {
 	t.Run("Error in Template", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: errors.New("Some error in template")}
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("1", "This restaurant deserves 9 Michelin stars")
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("1", "This restaurant deserves 9 Michelin stars", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
 		mock.ExpectQuery(statement).WillReturnRows(mockRow)
 		mockRDB := &mockReviewDB{Database: mockDB}
 
@@ -199,11 +247,28 @@ func TestGetReviewIntegrationService(t *testing.T) {
 		testHandler(t, mockHandler.GetReview, GET, url+id, nil, vars, http.StatusInternalServerError)
 	})
 
+	t.Run("Not Approved", func(t *testing.T) {
+		for _, status := range []string{"pending", "rejected"} {
+			mockTmpl := &mockTemplate{errMsg: nil}
+
+			mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow("1", "This restaurant deserves 9 Michelin stars", status, "neutral", 0.0, reviewedAt, reviewedAt, 0)
+			mock.ExpectQuery(statement).WillReturnRows(mockRow)
+			mockRDB := &mockReviewDB{Database: mockDB}
+
+			mockHandler := constructHandler(mockTmpl, mockRDB, nil)
+
+			id := "1"
+			vars := map[string]string{"reviewID": id}
+			testHandler(t, mockHandler.GetReview, GET, url+id, nil, vars, http.StatusNotFound)
+		}
+	})
+
 	t.Run("Happy Path", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: nil}
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("1", "This restaurant deserves 9 Michelin stars")
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("1", "This restaurant deserves 9 Michelin stars", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
 		mock.ExpectQuery(statement).WillReturnRows(mockRow)
 		mockRDB := &mockReviewDB{Database: mockDB}
 
@@ -220,7 +285,7 @@ func TestGetReviewsByKeywordIntegrationService(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	statementDict := "SELECT keyword FROM dictionary WHERE keyword = ?"
+	statementDict := "SELECT keyword FROM dictionary WHERE keyword = $1"
 
 	dbRev, mockRev, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
@@ -263,8 +328,8 @@ func TestGetReviewsByKeywordIntegrationService(t *testing.T) {
 			WillReturnRows(mockDictRow)
 		mockDictDB := &mockDictionaryDB{Database: dbDict}
 
-		mockRevStatement := reviewStatement(foodKeyword)
-		mockRev.ExpectQuery(mockRevStatement).
+		mockRev.ExpectQuery(keywordStatement).
+			WithArgs("approved", foodKeyword).
 			WillReturnError(sql.ErrNoRows)
 		mockRevDB := &mockReviewDB{Database: dbRev}
 
@@ -284,8 +349,8 @@ func TestGetReviewsByKeywordIntegrationService(t *testing.T) {
 			WillReturnRows(mockDictRow)
 		mockDictDB := &mockDictionaryDB{Database: dbDict}
 
-		mockRevStatement := reviewStatement(foodKeyword)
-		mockRev.ExpectQuery(mockRevStatement).
+		mockRev.ExpectQuery(keywordStatement).
+			WithArgs("approved", foodKeyword).
 			WillReturnError(errors.New("Some other error in review db"))
 		mockRevDB := &mockReviewDB{Database: dbRev}
 
@@ -295,6 +360,23 @@ func TestGetReviewsByKeywordIntegrationService(t *testing.T) {
 		testHandler(t, mockHandler.GetReviewsByKeyword, GET, url, nil, nil, http.StatusInternalServerError)
 	})
 
+	t.Run("Invalid Sentiment Filter", func(t *testing.T) {
+		foodKeyword := "foie gras"
+
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockDictRow := sqlmock.NewRows([]string{"keyword"}).AddRow(foodKeyword)
+		mockDict.ExpectQuery(statementDict).
+			WillReturnRows(mockDictRow)
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mockRevDB := &mockReviewDB{Database: dbRev}
+
+		mockHandler := constructHandler(mockTmpl, mockRevDB, mockDictDB)
+
+		url := "/reviews?query=" + foodKeyword + "&sentiment=furious"
+		testHandler(t, mockHandler.GetReviewsByKeyword, GET, url, nil, nil, http.StatusBadRequest)
+	})
+
 	t.Run("Template Error", func(t *testing.T) {
 		foodKeyword := "foie gras"
 
@@ -305,10 +387,10 @@ func TestGetReviewsByKeywordIntegrationService(t *testing.T) {
 			WillReturnRows(mockDictRow)
 		mockDictDB := &mockDictionaryDB{Database: dbDict}
 
-		mockRevStatement := reviewStatement(foodKeyword)
-		mockRevRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("8888", "this restaurant sucks")
-		mockRev.ExpectQuery(mockRevStatement).
+		mockRevRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("8888", "this restaurant sucks", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
+		mockRev.ExpectQuery(keywordStatement).
+			WithArgs("approved", foodKeyword).
 			WillReturnRows(mockRevRow)
 		mockRevDB := &mockReviewDB{Database: dbRev}
 
@@ -328,10 +410,10 @@ func TestGetReviewsByKeywordIntegrationService(t *testing.T) {
 			WillReturnRows(mockDictRow)
 		mockDictDB := &mockDictionaryDB{Database: dbDict}
 
-		mockRevStatement := reviewStatement(foodKeyword)
-		mockRevRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("8888", "this restaurant sucks")
-		mockRev.ExpectQuery(mockRevStatement).
+		mockRevRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("8888", "this restaurant sucks", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
+		mockRev.ExpectQuery(keywordStatement).
+			WithArgs("approved", foodKeyword).
 			WillReturnRows(mockRevRow)
 		mockRevDB := &mockReviewDB{Database: dbRev}
 
@@ -350,7 +432,7 @@ func TestAccessReviewEditIntegrationService(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error not expected while opening mock db, %v", err)
 	}
-	statement := "SELECT review_id, review FROM review WHERE review_id = ?"
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1"
 
 	t.Run("Invalid ID", func(t *testing.T) {
 		testSuite := []string{
@@ -387,8 +469,8 @@ func TestAccessReviewEditIntegrationService(t *testing.T) {
 	t.Run("Error in Template", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: errors.New("Some error in template")}
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("1", "This restaurant deserves 9 Michelin stars")
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("1", "This restaurant deserves 9 Michelin stars", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
 		mock.ExpectQuery(statement).WillReturnRows(mockRow)
 		mockRDB := &mockReviewDB{Database: mockDB}
 
@@ -402,8 +484,8 @@ func TestAccessReviewEditIntegrationService(t *testing.T) {
 	t.Run("Happy Path", func(t *testing.T) {
 		mockTmpl := &mockTemplate{errMsg: nil}
 
-		mockRow := sqlmock.NewRows([]string{"review_id", "review"}).
-			AddRow("1", "This restaurant deserves 9 Michelin stars")
+		mockRow := sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+			AddRow("1", "This restaurant deserves 9 Michelin stars", "approved", "neutral", 0.0, reviewedAt, reviewedAt, 0)
 		mock.ExpectQuery(statement).WillReturnRows(mockRow)
 		mockRDB := &mockReviewDB{Database: mockDB}
 
@@ -422,14 +504,22 @@ func TestEditReviewConcurrency(t *testing.T) {
 			t.Error(err)
 		}
 
+		dbDict, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Error(err)
+		}
+
 		mockTmpl := &mockTemplate{errMsg: nil}
-		mockDictDB := &mockDictionaryDB{Database: nil}
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+
+		mockRev.ExpectQuery("SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1").
+			WillReturnError(sql.ErrNoRows)
 
 		mockRev.ExpectBegin()
 
-		mockRev.ExpectPrepare("UPDATE review SET review = ? WHERE review_id = ?").
+		mockRev.ExpectPrepare("UPDATE review SET review = $1, status = $2, sentiment = $3, sentiment_score = $4, content_hash = $5, simhash = $6, updated_at = $7, edited_count = edited_count + 1 WHERE review_id = $8").
 			ExpectExec().
-			WithArgs("This is great", uint(1)).
+			WithArgs("This is great", "pending", "positive", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), uint(1)).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mockRev.ExpectCommit().
@@ -486,3 +576,144 @@ func TestEditReviewConcurrency(t *testing.T) {
 		assert.True(t, completionTime[1].After(completionTime[0]))
 	})
 }
+
+func TestGetSentimentBreakdownIntegrationService(t *testing.T) {
+	url := "/sentiment"
+
+	dbDict, mockDict, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	dbRev, mockRev, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	statementDict := "SELECT keyword FROM dictionary ORDER BY keyword"
+	statementRev := "SELECT sentiment, COUNT(*) FROM review WHERE status = $1 AND review LIKE '%' || $2 || '%' GROUP BY sentiment"
+
+	t.Run("Dictionary Error", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockDict.ExpectQuery(statementDict).
+			WillReturnError(errors.New("Some error in keyword db"))
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+
+		mockHandler := constructHandler(mockTmpl, nil, mockDictDB)
+
+		testHandler(t, mockHandler.GetSentimentBreakdown, GET, url, nil, nil, http.StatusInternalServerError)
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockDict.ExpectQuery(statementDict).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("som tam"))
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mockRev.ExpectQuery(statementRev).
+			WillReturnRows(sqlmock.NewRows([]string{"sentiment", "count"}).AddRow("negative", 2))
+		mockRevDB := &mockReviewDB{Database: dbRev}
+
+		mockHandler := constructHandler(mockTmpl, mockRevDB, mockDictDB)
+
+		testHandler(t, mockHandler.GetSentimentBreakdown, GET, url, nil, nil, http.StatusOK)
+	})
+}
+
+func TestGetKeywordAspectsIntegrationService(t *testing.T) {
+	dbDict, mockDict, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	dbRev, mockRev, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Error(err)
+	}
+	statementDict := "SELECT keyword FROM dictionary WHERE keyword = $1"
+
+	t.Run("Keyword Not Present", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockDict.ExpectQuery(statementDict).
+			WillReturnError(sql.ErrNoRows)
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+
+		mockHandler := constructHandler(mockTmpl, nil, mockDictDB)
+
+		vars := map[string]string{"keyword": "laab"}
+		testHandler(t, mockHandler.GetKeywordAspects, GET, "/stats/keywords/laab", nil, vars, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mockDict.ExpectQuery(statementDict).
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("laab"))
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mockRev.ExpectQuery(keywordStatement).
+			WithArgs("approved", "laab").
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow("3", "Laab was too salty", "approved", "negative", -0.25, reviewedAt, reviewedAt, 0))
+		mockRevDB := &mockReviewDB{Database: dbRev}
+
+		mockHandler := constructHandler(mockTmpl, mockRevDB, mockDictDB)
+
+		vars := map[string]string{"keyword": "laab"}
+		testHandler(t, mockHandler.GetKeywordAspects, GET, "/stats/keywords/laab", nil, vars, http.StatusOK)
+	})
+}
+
+func TestGetStatsIntegrationService(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error not expected while opening mock db, %v", err)
+	}
+
+	t.Run("Invalid Days", func(t *testing.T) {
+		testSuite := []string{"0", "-3", "abc", "1000"}
+
+		for _, testCase := range testSuite {
+			mockTmpl := &mockTemplate{errMsg: nil}
+			mockHandler := constructHandler(mockTmpl, nil, nil)
+
+			testHandler(t, mockHandler.GetStats, GET, "/stats?days="+testCase, nil, nil, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("DB Error", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mock.ExpectQuery("SELECT COUNT(*) FROM review WHERE status = $1").
+			WillReturnError(errors.New("Some error in review db"))
+		mockRDB := &mockReviewDB{Database: mockDB}
+
+		mockHandler := constructHandler(mockTmpl, mockRDB, nil)
+
+		testHandler(t, mockHandler.GetStats, GET, "/stats", nil, nil, http.StatusInternalServerError)
+	})
+}
+
+func TestPreviewReview(t *testing.T) {
+	handler := &route.Handler{Markdown: &markdown.Renderer{}}
+
+	preview := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.PreviewReview(w, httptest.NewRequest("POST", "/reviews/preview", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Renders Markdown", func(t *testing.T) {
+		w := preview(`{"review": "**Crispy** <script>alert(1)</script>"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "<p><strong>Crispy</strong> &lt;script&gt;alert(1)&lt;/script&gt;</p>", w.Body.String())
+	})
+
+	t.Run("Invalid Body", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, preview(`{"review": `).Code)
+	})
+
+	t.Run("Too Long", func(t *testing.T) {
+		w := preview(`{"review": "` + strings.Repeat("a", 65<<10) + `"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
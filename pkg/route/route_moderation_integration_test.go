@@ -0,0 +1,233 @@
+package route_test
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"food-review/pkg/profanity"
+	"food-review/pkg/spam"
+)
+
+const POST string = http.MethodPost
+
+func TestCreateReviewIntegrationService(t *testing.T) {
+	url := "/reviews"
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error not expected while opening mock db, %v", err)
+	}
+	dbDict, mockDict, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error not expected while opening mock db, %v", err)
+	}
+	statement := "INSERT INTO review (review, status, sentiment, sentiment_score, content_hash, simhash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING review_id"
+	duplicateStatement := "SELECT review_id FROM review WHERE content_hash = $1 AND review_id != $2 LIMIT 1"
+
+	t.Run("Empty Review", func(t *testing.T) {
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		body := strings.NewReader(`{"review": ""}`)
+		testHandler(t, mockHandler.CreateReview, POST, url, body, nil, http.StatusBadRequest)
+	})
+
+	t.Run("Duplicate Review", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow("4"))
+		mock.ExpectRollback()
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		body := strings.NewReader(`{"review": "Fluffy souffle pancakes"}`)
+		testHandler(t, mockHandler.CreateReview, POST, url, body, nil, http.StatusConflict)
+	})
+
+	t.Run("Rejected As Spam", func(t *testing.T) {
+		rules := spam.DefaultRules()
+		rules.RejectThreshold = rules.Threshold
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+		mockHandler.Spam = spam.NewChecker(rules)
+
+		body := strings.NewReader(`{"review": "Click here for free money"}`)
+		testHandler(t, mockHandler.CreateReview, POST, url, body, nil, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Rejected For Profanity", func(t *testing.T) {
+		filter := profanity.DefaultFilter()
+		filter.Mode = profanity.ModeReject
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+		mockHandler.Profanity = filter
+
+		body := strings.NewReader(`{"review": "Sh1t pancakes"}`)
+		testHandler(t, mockHandler.CreateReview, POST, url, body, nil, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(duplicateStatement).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(statement).
+			WithArgs("Fluffy souffle pancakes", "pending", "neutral", 0.0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(10))
+		mock.ExpectQuery("SELECT review_id, simhash FROM review WHERE content_hash != '' ORDER BY review_id").
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}))
+		mock.ExpectCommit()
+		mockRDB := &mockReviewDB{Database: mockDB}
+
+		mockDict.ExpectQuery("SELECT keyword FROM dictionary ORDER BY keyword").
+			WillReturnRows(sqlmock.NewRows([]string{"keyword"}).AddRow("pancakes").AddRow("waffles"))
+		mockDictDB := &mockDictionaryDB{Database: dbDict}
+		mock.ExpectQuery("SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1").
+			WithArgs(uint(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow("10", "Fluffy souffle pancakes", "pending", "neutral", 0.0, reviewedAt, reviewedAt, 0))
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM keyword_mention WHERE review_id = $1").
+			WithArgs(uint(10)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO keyword_mention (review_id, keyword, mentions) VALUES ($1, $2, $3)").
+			WithArgs(uint(10), "pancakes", 1).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		mockHandler := constructHandler(nil, mockRDB, mockDictDB)
+
+		body := strings.NewReader(`{"review": "Fluffy souffle pancakes"}`)
+		testHandler(t, mockHandler.CreateReview, POST, url, body, nil, http.StatusCreated)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestFlagReviewIntegrationService(t *testing.T) {
+	url := "/reviews/1/flag"
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error not expected while opening mock db, %v", err)
+	}
+	statement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1"
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		mockHandler := constructHandler(nil, nil, nil)
+
+		vars := map[string]string{"reviewID": "abc"}
+		testHandler(t, mockHandler.FlagReview, POST, url, nil, vars, http.StatusBadRequest)
+	})
+
+	t.Run("Invalid Reason", func(t *testing.T) {
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		vars := map[string]string{"reviewID": "1"}
+		body := strings.NewReader(`{"reason": "too spicy"}`)
+		testHandler(t, mockHandler.FlagReview, POST, url, body, vars, http.StatusBadRequest)
+	})
+
+	t.Run("No Review with this ID", func(t *testing.T) {
+		mock.ExpectQuery(statement).WillReturnError(sql.ErrNoRows)
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		vars := map[string]string{"reviewID": "1"}
+		body := strings.NewReader(`{"reason": "spam"}`)
+		testHandler(t, mockHandler.FlagReview, POST, url, body, vars, http.StatusUnprocessableEntity)
+	})
+}
+
+func TestModerationIntegrationService(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error not expected while opening mock db, %v", err)
+	}
+	queueStatement := `SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review
+		WHERE status = $1
+		OR review_id IN (SELECT review_id FROM review_flag WHERE NOT resolved)
+		ORDER BY review_id`
+	reviewStatement := "SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1"
+
+	t.Run("Empty Queue", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mock.ExpectQuery(queueStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}))
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(mockTmpl, mockRDB, nil)
+
+		testHandler(t, mockHandler.GetModerationQueue, GET, "/moderation", nil, nil, http.StatusOK)
+	})
+
+	t.Run("Approve Missing Review", func(t *testing.T) {
+		mock.ExpectQuery(reviewStatement).WillReturnError(sql.ErrNoRows)
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		vars := map[string]string{"reviewID": "5"}
+		testHandler(t, mockHandler.ApproveReview, POST, "/moderation/5/approve", strings.NewReader(""), vars, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Reject Happy Path", func(t *testing.T) {
+		mock.ExpectQuery(reviewStatement).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "review", "status", "sentiment", "sentiment_score", "created_at", "updated_at", "edited_count"}).
+				AddRow("5", "Click here for free food", "pending", "neutral", 0.0, reviewedAt, reviewedAt, 0))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE review SET status = $1 WHERE review_id = $2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE review_flag SET resolved = TRUE WHERE review_id = $1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO review_audit (review_id, action, note, moderator) VALUES ($1, $2, $3, $4)").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		vars := map[string]string{"reviewID": "5"}
+		body := strings.NewReader(`{"note": "spam link"}`)
+		testHandler(t, mockHandler.RejectReview, POST, "/moderation/5/reject", body, vars, http.StatusOK)
+	})
+}
+
+func TestDuplicatesIntegrationService(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error not expected while opening mock db, %v", err)
+	}
+
+	t.Run("No Clusters", func(t *testing.T) {
+		mockTmpl := &mockTemplate{errMsg: nil}
+
+		mock.ExpectQuery("SELECT review_id, simhash FROM review WHERE content_hash != '' ORDER BY review_id").
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "simhash"}))
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(mockTmpl, mockRDB, nil)
+
+		testHandler(t, mockHandler.GetDuplicateClusters, GET, "/admin/duplicates", nil, nil, http.StatusOK)
+	})
+
+	t.Run("Merge Needs Two Reviews", func(t *testing.T) {
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		body := strings.NewReader(`{"review_ids": [1]}`)
+		testHandler(t, mockHandler.MergeReviews, POST, "/admin/duplicates/merge", body, nil, http.StatusBadRequest)
+	})
+
+	t.Run("Merge Missing Review", func(t *testing.T) {
+		mock.ExpectQuery("SELECT review_id, review, status, sentiment, sentiment_score, created_at, updated_at, edited_count FROM review WHERE review_id = $1").
+			WillReturnError(sql.ErrNoRows)
+		mockRDB := &mockReviewDB{Database: mockDB}
+		mockHandler := constructHandler(nil, mockRDB, nil)
+
+		body := strings.NewReader(`{"review_ids": [1, 2]}`)
+		testHandler(t, mockHandler.MergeReviews, POST, "/admin/duplicates/merge", body, nil, http.StatusUnprocessableEntity)
+	})
+}
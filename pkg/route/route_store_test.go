@@ -0,0 +1,241 @@
+package route_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/markdown"
+	"food-review/pkg/model"
+	"food-review/pkg/profanity"
+	"food-review/pkg/route"
+	"food-review/pkg/spam"
+	"food-review/pkg/store"
+)
+
+// These tests run the handlers against the in-memory stores, so they need no
+// SQL expectations.
+func constructMemoryHandler(keywords ...string) (*route.Handler, *store.MemoryReviewStore) {
+	reviews := store.NewMemoryReviewStore()
+	handler := &route.Handler{
+		Template:   &mockTemplate{},
+		Reviews:    reviews,
+		Dictionary: store.NewMemoryDictionaryStore(keywords...),
+	}
+	return handler, reviews
+}
+
+func TestMemoryStoreIntegrationService(t *testing.T) {
+	t.Run("Get Review", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler()
+		reviewID := reviews.Insert(model.Review{Content: "Crispy roti", Status: model.StatusApproved})
+		assert.Equal(t, uint(1), reviewID)
+
+		testHandler(t, handler.GetReview, GET, "/reviews/1", nil, map[string]string{"reviewID": "1"}, http.StatusOK)
+		testHandler(t, handler.GetReview, GET, "/reviews/2", nil, map[string]string{"reviewID": "2"}, http.StatusUnprocessableEntity)
+
+		for _, status := range []string{model.StatusPending, model.StatusRejected} {
+			reviewID := reviews.Insert(model.Review{Content: "Hidden roti " + status, Status: status})
+			id := strconv.FormatUint(uint64(reviewID), 10)
+			testHandler(t, handler.GetReview, GET, "/reviews/"+id, nil, map[string]string{"reviewID": id}, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Search By Keyword", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler("roti")
+		reviews.Insert(model.Review{Content: "Crispy roti", Status: model.StatusApproved})
+
+		testHandler(t, handler.GetReviewsByKeyword, GET, "/reviews?query=roti", nil, nil, http.StatusOK)
+		testHandler(t, handler.GetReviewsByKeyword, GET, "/reviews?query=satay", nil, nil, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Create Then Edit", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler("roti")
+
+		body := strings.NewReader(`{"review": "Crispy roti"}`)
+		testHandler(t, handler.CreateReview, POST, "/reviews", body, nil, http.StatusCreated)
+
+		// Pending reviews can't be edited, or read on the edit page.
+		vars := map[string]string{"reviewID": "1"}
+		testHandler(t, handler.AccessReviewEdit, GET, "/reviews/1/edit", nil, vars, http.StatusNotFound)
+		body = strings.NewReader(`{"review": "Sneaky roti"}`)
+		testHandler(t, handler.EditReview, PUT, "/reviews/1", body, vars, http.StatusNotFound)
+
+		assert.NoError(t, reviews.Approve(1, "anna", nil))
+		testHandler(t, handler.AccessReviewEdit, GET, "/reviews/1/edit", nil, vars, http.StatusOK)
+		body = strings.NewReader(`{"review": "Soggy roti"}`)
+		testHandler(t, handler.EditReview, PUT, "/reviews/1", body, vars, http.StatusOK)
+		review, err := reviews.Get(1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Soggy roti", review.Content)
+			assert.Equal(t, model.StatusPending, review.Status)
+		}
+
+		body = strings.NewReader(`{"review": "Ghost roti"}`)
+		testHandler(t, handler.EditReview, PUT, "/reviews/9", body, map[string]string{"reviewID": "9"}, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("Spam Is Flagged", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler()
+		handler.Spam = spam.NewChecker(spam.DefaultRules())
+
+		body := strings.NewReader(`{"review": "Great roti, even better casino upstairs"}`)
+		testHandler(t, handler.CreateReview, POST, "/reviews", body, nil, http.StatusCreated)
+		queue, err := reviews.ModerationQueue()
+		if assert.NoError(t, err) && assert.Len(t, queue, 1) && assert.Len(t, queue[0].Flags, 1) {
+			assert.Equal(t, model.FlagSpam, queue[0].Flags[0].Reason)
+		}
+	})
+
+	t.Run("Profanity Is Flagged", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler()
+		handler.Spam = spam.NewChecker(spam.DefaultRules())
+		filter := profanity.DefaultFilter()
+		filter.Mode = profanity.ModeFlag
+		handler.Profanity = filter
+
+		body := strings.NewReader(`{"review": "Shit, the casino upstairs has better roti"}`)
+		testHandler(t, handler.CreateReview, POST, "/reviews", body, nil, http.StatusCreated)
+		queue, err := reviews.ModerationQueue()
+		if assert.NoError(t, err) && assert.Len(t, queue, 1) && assert.Len(t, queue[0].Flags, 2) {
+			assert.Equal(t, model.FlagSpam, queue[0].Flags[0].Reason)
+			assert.Equal(t, model.FlagOffensive, queue[0].Flags[1].Reason)
+		}
+	})
+
+	t.Run("Delete Review", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler()
+		reviews.Insert(model.Review{Content: "Crispy roti", Status: model.StatusApproved})
+
+		testHandler(t, handler.DeleteReview, http.MethodDelete, "/reviews/1", nil, map[string]string{"reviewID": "1"}, http.StatusNoContent)
+		testHandler(t, handler.DeleteReview, http.MethodDelete, "/reviews/1", nil, map[string]string{"reviewID": "1"}, http.StatusUnprocessableEntity)
+		testHandler(t, handler.DeleteReview, http.MethodDelete, "/reviews/x", nil, map[string]string{"reviewID": "x"}, http.StatusBadRequest)
+	})
+
+	t.Run("Keyword Candidates", func(t *testing.T) {
+		handler, reviews := constructMemoryHandler("larb")
+		handler.Candidates = &route.KeywordScan{Reviews: handler.Reviews, Dictionary: handler.Dictionary}
+		reviews.Insert(model.Review{Content: "Khao soi with larb", Status: model.StatusApproved})
+		reviews.Insert(model.Review{Content: "More khao soi please", Status: model.StatusApproved})
+
+		testHandler(t, handler.ScanKeywordCandidates, POST, "/admin/keywords/scan", nil, nil, http.StatusNoContent)
+		candidates, scannedAt := handler.Candidates.Latest([]string{"larb"})
+		assert.False(t, scannedAt.IsZero())
+		if assert.NotEmpty(t, candidates) {
+			assert.Equal(t, "khao soi", candidates[0].Term)
+		}
+
+		// A candidate accepted since the scan drops off the stored result.
+		candidates, _ = handler.Candidates.Latest([]string{"khao soi", "larb"})
+		for _, candidate := range candidates {
+			assert.NotEqual(t, "khao soi", candidate.Term)
+		}
+	})
+}
+
+func TestFeeds(t *testing.T) {
+	handler, reviews := constructMemoryHandler("roti", "satay")
+	handler.Markdown = &markdown.Renderer{}
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	reviews.Insert(model.Review{Content: "Crispy *roti*", Status: model.StatusApproved, CreatedAt: published, UpdatedAt: published.Add(48 * time.Hour)})
+	reviews.Insert(model.Review{Content: "Sweet satay sauce", Status: model.StatusApproved, CreatedAt: published.Add(24 * time.Hour)})
+	reviews.Insert(model.Review{Content: "Pending roti", Status: model.StatusPending, CreatedAt: published})
+
+	get := func(handle http.HandlerFunc, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(GET, url, nil)
+		r.Header.Set("X-Forwarded-Proto", "https")
+		r.Host = "food.example"
+		handle(w, r)
+		return w
+	}
+
+	t.Run("Atom", func(t *testing.T) {
+		w := get(handler.GetReviewsAtom, "/feeds/reviews.atom")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/atom+xml; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, `<link rel="self" type="application/atom+xml" href="https://food.example/feeds/reviews.atom"></link>`)
+		assert.Contains(t, body, "<updated>2024-03-03T12:00:00Z</updated>")
+		assert.Contains(t, body, "&lt;p&gt;Crispy &lt;em&gt;roti&lt;/em&gt;&lt;/p&gt;")
+		assert.NotContains(t, body, "Pending roti")
+		assert.Less(t, strings.Index(body, "reviews/1"), strings.Index(body, "reviews/2"), "most recently updated first")
+	})
+
+	t.Run("RSS", func(t *testing.T) {
+		w := get(handler.GetReviewsRSS, "/feeds/reviews.rss")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/rss+xml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<pubDate>Fri, 01 Mar 2024 12:00:00 +0000</pubDate>")
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		w := get(handler.GetSearchAtom, "/feeds/search.atom?query=satay")
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `href="https://food.example/reviews?query=satay"`)
+		assert.Contains(t, body, "<title>Sweet satay sauce</title>")
+		assert.NotContains(t, body, "roti")
+	})
+
+	t.Run("Search Without Matches", func(t *testing.T) {
+		reviews.Delete(2)
+		w := get(handler.GetSearchAtom, "/feeds/search.atom?query=satay")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "<entry>")
+	})
+
+	t.Run("Unknown Keyword", func(t *testing.T) {
+		w := get(handler.GetSearchAtom, "/feeds/search.atom?query=larb")
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestReviewDateFilter(t *testing.T) {
+	handler, reviews := constructMemoryHandler("roti")
+	template := handler.Template.(*mockTemplate)
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	reviews.Insert(model.Review{Content: "First roti", Status: model.StatusApproved, CreatedAt: day, UpdatedAt: day.AddDate(0, 0, 5)})
+	reviews.Insert(model.Review{Content: "Second roti", Status: model.StatusApproved, CreatedAt: day.AddDate(0, 0, 1)})
+	reviews.Insert(model.Review{Content: "Third roti", Status: model.StatusApproved, CreatedAt: day.AddDate(0, 0, 2)})
+
+	listed := func() []uint {
+		var ids []uint
+		for _, review := range template.data.(route.ReviewPage).Reviews {
+			ids = append(ids, review.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		url  string
+		want []uint
+	}{
+		{"/reviews", []uint{1, 2, 3}},
+		{"/reviews?from=2024-03-02", []uint{2, 3}},
+		{"/reviews?to=2024-03-02", []uint{1, 2}},
+		{"/reviews?from=2024-03-02&to=2024-03-02", []uint{2}},
+		{"/reviews?sort=newest", []uint{3, 2, 1}},
+		{"/reviews?sort=oldest&from=2024-03-02", []uint{2, 3}},
+		{"/reviews?sort=updated", []uint{1, 3, 2}},
+		{"/reviews?query=roti&sort=newest&to=2024-03-02", []uint{2, 1}},
+	}
+	for _, test := range tests {
+		handle := handler.GetAllReviews
+		if strings.Contains(test.url, "query=") {
+			handle = handler.GetReviewsByKeyword
+		}
+		testHandler(t, handle, GET, test.url, nil, nil, http.StatusOK)
+		assert.Equal(t, test.want, listed(), test.url)
+	}
+
+	for _, url := range []string{"/reviews?from=yesterday", "/reviews?to=2024-13-01", "/reviews?from=2024-03-02&to=2024-03-01", "/reviews?sort=best"} {
+		testHandler(t, handler.GetAllReviews, GET, url, nil, nil, http.StatusBadRequest)
+	}
+}
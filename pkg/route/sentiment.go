@@ -0,0 +1,57 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) GetSentimentBreakdown(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	keywords, err := h.dictionary(r).List()
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	breakdown, err := h.reviews(r).KeywordSentiments(keywords)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = h.executeTemplate(w, r, "sentiment.html", breakdown)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) GetKeywordAspects(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	keyword := mux.Vars(r)["keyword"]
+	exist, err := h.dictionary(r).KeywordExists(keyword)
+	if !exist {
+		if err == nil {
+			clientError(w, r, http.StatusUnprocessableEntity, "Keyword not in dictionary")
+		} else {
+			serverError(w, r, err)
+		}
+
+		return
+	}
+
+	report, err := h.reviews(r).KeywordAspects(keyword)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = h.executeTemplate(w, r, "keyword_stats.html", report)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,31 @@
+package route
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"food-review/pkg/model"
+)
+
+// screenSpam runs the spam checker over a submitted review body and returns
+// the flags the store should raise on the review, or model.ErrSpamRejected
+// when the checker rejects it outright.
+func (h *Handler) screenSpam(r *http.Request, reviewBody []byte) ([]model.Flag, error) {
+	review := model.Review{}
+	if err := json.Unmarshal(reviewBody, &review); err != nil {
+		// The model reports malformed bodies.
+		return nil, nil
+	}
+	if strings.TrimSpace(review.Content) == "" {
+		// Nor do empty reviews count against the sender's rate.
+		return nil, nil
+	}
+
+	verdict, err := model.ScreenReview(h.Spam, review.Content, clientIP(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return model.SpamFlags(verdict), nil
+}
@@ -0,0 +1,58 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"food-review/pkg/logging"
+)
+
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 365
+)
+
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	days := defaultStatsDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 1 || parsed > maxStatsDays {
+			clientError(w, r, http.StatusBadRequest, "Invalid number of days")
+			return
+		}
+		days = parsed
+	}
+
+	stats, err := h.reviews(r).Stats(days, time.Now())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = h.executeTemplate(w, r, "stats.html", stats)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+}
+
+// refreshMentions keeps keyword_mention in step with a review that was just
+// written. The review itself is already saved, so a failure here only leaves
+// stats stale and is logged rather than returned to the client.
+func (h *Handler) refreshMentions(r *http.Request, reviewID uint) {
+	logger := logging.FromContext(r.Context())
+
+	keywords, err := h.dictionary(r).List()
+	if err != nil {
+		logger.Error("refresh keyword mentions", "review_id", reviewID, "error", err)
+		return
+	}
+
+	err = h.reviews(r).RefreshKeywordMentions(reviewID, keywords)
+	if err != nil {
+		logger.Error("refresh keyword mentions", "review_id", reviewID, "error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package sentiment
+
+import (
+	"regexp"
+	"strings"
+)
+
+// clauseSplitter breaks a review into the clauses aspects are scored on:
+// sentence punctuation, line breaks and contrastive conjunctions. Thai "แต่"
+// is only a conjunction when it isn't the start of a longer word like "แต่ง".
+var clauseSplitter = regexp.MustCompile(`[.!?;,\n]+|(?i)\b(?:but|however)\b|แต่(?:[^ง]|$)`)
+
+type Mention struct {
+	Keyword string
+	Excerpt string
+	Result
+}
+
+// Aspects scores the clause around every mention of each keyword, so "the
+// curry was great but the rice was soggy" praises curry and criticises rice.
+func Aspects(text string, keywords []string) []Mention {
+	return defaultAnalyzer.Aspects(text, keywords)
+}
+
+func (a *Analyzer) Aspects(text string, keywords []string) []Mention {
+	var mentions []Mention
+
+	for _, clause := range SplitClauses(text) {
+		lowered := strings.ToLower(clause)
+		for _, keyword := range keywords {
+			if keyword == "" || !strings.Contains(lowered, strings.ToLower(keyword)) {
+				continue
+			}
+			mentions = append(mentions, Mention{
+				Keyword: keyword,
+				Excerpt: clause,
+				Result:  a.Score(clause),
+			})
+		}
+	}
+
+	return mentions
+}
+
+func SplitClauses(text string) []string {
+	var clauses []string
+
+	last := 0
+	for _, loc := range clauseSplitter.FindAllStringIndex(text, -1) {
+		end := loc[1]
+		if strings.HasPrefix(text[loc[0]:], "แต่") && end-loc[0] > len("แต่") {
+			// Give back the rune after "แต่" that the pattern had to look at.
+			end = loc[0] + len("แต่")
+		}
+		clauses = appendClause(clauses, text[last:loc[0]])
+		last = end
+	}
+	clauses = appendClause(clauses, text[last:])
+
+	return clauses
+}
+
+func appendClause(clauses []string, clause string) []string {
+	clause = strings.TrimSpace(clause)
+	if clause == "" {
+		return clauses
+	}
+	return append(clauses, clause)
+}
@@ -0,0 +1,302 @@
+package sentiment
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	Positive = "positive"
+	Negative = "negative"
+	Neutral  = "neutral"
+)
+
+// negationWindow is how many tokens after a negator have their polarity flipped.
+const negationWindow = 3
+
+// neutralBand is the normalised score range treated as neither positive nor negative.
+const neutralBand = 0.05
+
+var Labels = []string{Positive, Negative, Neutral}
+
+// clauseWords split a sentence into clauses that are scored for negation
+// independently, e.g. "not crispy but tasty".
+var clauseWords = map[string]bool{
+	"but":     true,
+	"however": true,
+	"แต่":     true,
+}
+
+//go:embed lexicon/*.txt
+var lexiconFS embed.FS
+
+var defaultAnalyzer = mustLoadDefault()
+
+type Result struct {
+	Score float64
+	Label string
+}
+
+type Analyzer struct {
+	Lexicon  map[string]float64
+	Negators map[string]bool
+	// longestThai is the length in runes of the longest Thai entry, used to
+	// bound the dictionary segmentation of Thai text which has no spaces.
+	longestThai int
+}
+
+func ValidLabel(label string) bool {
+	for _, l := range Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func Score(text string) Result {
+	return defaultAnalyzer.Score(text)
+}
+
+func mustLoadDefault() *Analyzer {
+	analyzer, err := LoadAnalyzer(lexiconFS, "lexicon/en.txt", "lexicon/th.txt")
+	if err != nil {
+		panic(err)
+	}
+	return analyzer
+}
+
+// LoadAnalyzer builds an Analyzer from word/score lexicon files. Negators are
+// read from negators.txt next to the first lexicon file when it exists.
+func LoadAnalyzer(fsys fs.FS, lexiconFiles ...string) (*Analyzer, error) {
+	analyzer := &Analyzer{
+		Lexicon:  map[string]float64{},
+		Negators: map[string]bool{},
+	}
+
+	for _, name := range lexiconFiles {
+		err := readLines(fsys, name, func(line string) error {
+			fields := strings.Split(line, "\t")
+			if len(fields) != 2 {
+				return fmt.Errorf("%s: expected word<TAB>score, got %q", name, line)
+			}
+			score, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			analyzer.add(fields[0], score)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(lexiconFiles) > 0 {
+		negatorFile := path.Join(path.Dir(lexiconFiles[0]), "negators.txt")
+		err := readLines(fsys, negatorFile, func(line string) error {
+			analyzer.addNegator(line)
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return analyzer, nil
+}
+
+func (a *Analyzer) add(word string, score float64) {
+	word = strings.ToLower(word)
+	a.Lexicon[word] = score
+	a.trackThai(word)
+}
+
+func (a *Analyzer) addNegator(word string) {
+	word = strings.ToLower(word)
+	a.Negators[word] = true
+	a.trackThai(word)
+}
+
+func (a *Analyzer) trackThai(word string) {
+	r, _ := utf8.DecodeRuneInString(word)
+	if unicode.Is(unicode.Thai, r) {
+		if n := utf8.RuneCountInString(word); n > a.longestThai {
+			a.longestThai = n
+		}
+	}
+}
+
+// Score sums the lexicon scores of every token, flipping the polarity of
+// tokens that follow a negator within the same clause, and normalises the
+// sum into the range (-1, 1).
+func (a *Analyzer) Score(text string) Result {
+	var total float64
+	negateFor := 0
+
+	for _, token := range a.Tokenize(text) {
+		if token == "" {
+			// Clause boundary.
+			negateFor = 0
+			continue
+		}
+		if a.Negators[token] {
+			negateFor = negationWindow
+			continue
+		}
+
+		if score, ok := a.Lexicon[token]; ok {
+			if negateFor > 0 {
+				score = -score
+			}
+			total += score
+		}
+		if negateFor > 0 {
+			negateFor--
+		}
+	}
+
+	normalized := total / math.Sqrt(total*total+15)
+	return Result{Score: normalized, Label: label(normalized)}
+}
+
+func label(score float64) string {
+	switch {
+	case score >= neutralBand:
+		return Positive
+	case score <= -neutralBand:
+		return Negative
+	default:
+		return Neutral
+	}
+}
+
+// Tokenize lowercases text and splits it into words. Thai runs are segmented
+// by longest match against the lexicon. Clause boundaries (punctuation,
+// clauseWords and the spaces Thai uses between sentences) are returned as
+// empty tokens.
+func (a *Analyzer) Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var thai []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			w := string(word)
+			if clauseWords[w] {
+				tokens = append(tokens, "")
+			} else {
+				tokens = append(tokens, w)
+			}
+			word = word[:0]
+		}
+	}
+	flushThai := func() {
+		if len(thai) > 0 {
+			tokens = append(tokens, a.segmentThai(thai)...)
+			thai = thai[:0]
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Thai, r):
+			flushWord()
+			thai = append(thai, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			flushThai()
+			word = append(word, r)
+		default:
+			endsThai := len(thai) > 0
+			flushWord()
+			flushThai()
+			if strings.ContainsRune(".,!?;", r) || (endsThai && unicode.IsSpace(r)) {
+				tokens = append(tokens, "")
+			}
+		}
+	}
+	flushWord()
+	flushThai()
+
+	return tokens
+}
+
+func (a *Analyzer) segmentThai(text []rune) []string {
+	var tokens []string
+	var unknown []rune
+
+	for start := 0; start < len(text); {
+		end := start + a.longestThai
+		if end > len(text) {
+			end = len(text)
+		}
+
+		matched := false
+		for ; end > start+1; end-- {
+			candidate := string(text[start:end])
+			if _, ok := a.Lexicon[candidate]; ok || a.Negators[candidate] || clauseWords[candidate] {
+				if len(unknown) > 0 {
+					tokens = append(tokens, string(unknown))
+					unknown = unknown[:0]
+				}
+				if clauseWords[candidate] {
+					candidate = ""
+				}
+				tokens = append(tokens, candidate)
+				start = end
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			// Runs of unknown runes become a single token so they don't
+			// exhaust a negation window one character at a time.
+			unknown = append(unknown, text[start])
+			start++
+		}
+	}
+	if len(unknown) > 0 {
+		tokens = append(tokens, string(unknown))
+	}
+
+	return tokens
+}
+
+func readLines(fsys fs.FS, name string, handle func(line string) error) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := handle(line); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Words lists the words the default lexicon scores.
+func Words() []string {
+	words := make([]string, 0, len(defaultAnalyzer.Lexicon))
+	for word := range defaultAnalyzer.Lexicon {
+		words = append(words, word)
+	}
+	return words
+}
@@ -0,0 +1,126 @@
+package sentiment_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/sentiment"
+)
+
+func TestScore(t *testing.T) {
+	testSuite := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"English Positive", "The curry was delicious and the staff friendly", sentiment.Positive},
+		{"English Negative", "Soggy rice and a rude waiter", sentiment.Negative},
+		{"No Lexicon Words", "We ordered rice and curry", sentiment.Neutral},
+		{"Negation Flips Polarity", "The noodles were not good", sentiment.Negative},
+		{"Negation Stops at Clause", "Not cheap, but delicious", sentiment.Positive},
+		{"Negation Window Ends", "It was not what we ordered at all but great", sentiment.Positive},
+		{"Thai Positive", "ต้มยำอร่อยมาก", sentiment.Positive},
+		{"Thai Negative", "ข้าวแฉะ พนักงานหยาบคาย", sentiment.Negative},
+		{"Thai Negation", "ก๋วยเตี๋ยวไม่ค่อยสด", sentiment.Negative},
+		{"Mixed Languages", "Pad thai อร่อย but a bit salty", sentiment.Positive},
+	}
+
+	for _, testCase := range testSuite {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := sentiment.Score(testCase.text)
+			assert.Equal(t, testCase.expected, result.Label, "score %v", result.Score)
+			assert.True(t, result.Score > -1 && result.Score < 1)
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	analyzer, err := sentiment.LoadAnalyzer(fstest.MapFS{
+		"words.txt": {Data: []byte("อร่อย\t3\n")},
+	}, "words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Thai Longest Match", func(t *testing.T) {
+		tokens := analyzer.Tokenize("ร้านนี้อร่อยแต่แพง")
+		assert.Equal(t, []string{"ร้านนี้", "อร่อย", "", "แพง"}, tokens)
+	})
+
+	t.Run("Punctuation Splits Clauses", func(t *testing.T) {
+		tokens := analyzer.Tokenize("Great food. Slow service")
+		assert.Equal(t, []string{"great", "food", "", "slow", "service"}, tokens)
+	})
+}
+
+func TestLoadAnalyzer(t *testing.T) {
+	t.Run("Malformed Line", func(t *testing.T) {
+		_, err := sentiment.LoadAnalyzer(fstest.MapFS{
+			"words.txt": {Data: []byte("tasty 2\n")},
+		}, "words.txt")
+		assert.ErrorContains(t, err, "expected word<TAB>score")
+	})
+
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := sentiment.LoadAnalyzer(fstest.MapFS{}, "words.txt")
+		assert.Error(t, err)
+	})
+
+	t.Run("Negators Next to Lexicon", func(t *testing.T) {
+		analyzer, err := sentiment.LoadAnalyzer(fstest.MapFS{
+			"lex/words.txt":    {Data: []byte("# comment\ntasty\t2\n")},
+			"lex/negators.txt": {Data: []byte("nope\n")},
+		}, "lex/words.txt")
+		if assert.NoError(t, err) {
+			assert.True(t, analyzer.Negators["nope"])
+			assert.Equal(t, sentiment.Negative, analyzer.Score("nope tasty").Label)
+		}
+	})
+}
+
+func TestAspects(t *testing.T) {
+	t.Run("Contrast Splits Praise and Criticism", func(t *testing.T) {
+		mentions := sentiment.Aspects("The curry was great but the rice was soggy", []string{"curry", "rice"})
+		if assert.Len(t, mentions, 2) {
+			assert.Equal(t, "curry", mentions[0].Keyword)
+			assert.Equal(t, sentiment.Positive, mentions[0].Label)
+			assert.Equal(t, "The curry was great", mentions[0].Excerpt)
+			assert.Equal(t, "rice", mentions[1].Keyword)
+			assert.Equal(t, sentiment.Negative, mentions[1].Label)
+		}
+	})
+
+	t.Run("Keyword Matched Case Insensitively", func(t *testing.T) {
+		mentions := sentiment.Aspects("Mango Sticky Rice was heavenly!", []string{"mango sticky rice"})
+		if assert.Len(t, mentions, 1) {
+			assert.Equal(t, sentiment.Positive, mentions[0].Label)
+		}
+	})
+
+	t.Run("Thai Contrast", func(t *testing.T) {
+		mentions := sentiment.Aspects("ต้มยำอร่อยแต่ข้าวแฉะ", []string{"ต้มยำ", "ข้าว"})
+		if assert.Len(t, mentions, 2) {
+			assert.Equal(t, sentiment.Positive, mentions[0].Label)
+			assert.Equal(t, sentiment.Negative, mentions[1].Label)
+		}
+	})
+
+	t.Run("No Mention", func(t *testing.T) {
+		mentions := sentiment.Aspects("Lovely place", []string{"curry"})
+		assert.Empty(t, mentions)
+	})
+}
+
+func TestSplitClauses(t *testing.T) {
+	t.Run("Thai Word Starting With Conjunction", func(t *testing.T) {
+		clauses := sentiment.SplitClauses("ร้านแต่งสวยแต่ช้า")
+		assert.Equal(t, []string{"ร้านแต่งสวย", "ช้า"}, clauses)
+	})
+
+	t.Run("Punctuation and Conjunctions", func(t *testing.T) {
+		clauses := sentiment.SplitClauses("Good noodles. Slow service, however friendly staff")
+		assert.Equal(t, []string{"Good noodles", "Slow service", "friendly staff"}, clauses)
+	})
+}
@@ -0,0 +1,262 @@
+package spam
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"food-review/pkg/fingerprint"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.|\b[a-z0-9-]+\.(?:com|net|org|info|biz|xyz|co|io|ly)\b`)
+
+// Duration reads a time.Duration written as a string like "10m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+
+	return nil
+}
+
+// Rules are the spam heuristics and their weights. A submission scoring at or
+// above Threshold goes to moderation flagged as spam; at or above
+// RejectThreshold it is refused outright. A zero RejectThreshold never rejects.
+type Rules struct {
+	Threshold       float64 `json:"threshold"`
+	RejectThreshold float64 `json:"reject_threshold"`
+
+	Links struct {
+		MaxDensity float64 `json:"max_density"`
+		Weight     float64 `json:"weight"`
+	} `json:"links"`
+
+	RepeatedCharacters struct {
+		MinRun int     `json:"min_run"`
+		Weight float64 `json:"weight"`
+	} `json:"repeated_characters"`
+
+	BlacklistedPhrases struct {
+		Phrases []string `json:"phrases"`
+		Weight  float64  `json:"weight"`
+	} `json:"blacklisted_phrases"`
+
+	SubmissionRate struct {
+		Max    int      `json:"max"`
+		Window Duration `json:"window"`
+		Weight float64  `json:"weight"`
+	} `json:"submission_rate"`
+
+	KnownSpam struct {
+		Samples []string `json:"samples"`
+		Weight  float64  `json:"weight"`
+	} `json:"known_spam"`
+}
+
+type Verdict struct {
+	Score   float64
+	Reasons []string
+	// Spam is set when the score reaches the moderation threshold.
+	Spam bool
+	// Reject is set when the score reaches the reject threshold.
+	Reject bool
+}
+
+type Checker struct {
+	Rules Rules
+	Now   func() time.Time
+
+	knownSpam []uint64
+	phrases   []string
+
+	mu     sync.Mutex
+	recent map[string][]time.Time
+}
+
+func ParseRules(data []byte) (Rules, error) {
+	rules := Rules{}
+
+	err := json.Unmarshal(data, &rules)
+	if err != nil {
+		return rules, err
+	}
+	if rules.Threshold <= 0 {
+		return rules, errors.New("spam rules: threshold must be positive")
+	}
+
+	return rules, nil
+}
+
+// LoadRules reads rules from path, or returns the bundled defaults when path
+// is empty. A path that was given but doesn't exist is an error, so a typo
+// can't quietly swap the configured rules for the defaults.
+func LoadRules(path string) (Rules, error) {
+	if path == "" {
+		return DefaultRules(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+
+	rules, err := ParseRules(data)
+	if err != nil {
+		return Rules{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+func DefaultRules() Rules {
+	rules, err := ParseRules(defaultRules)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+func NewChecker(rules Rules) *Checker {
+	checker := &Checker{
+		Rules:  rules,
+		Now:    time.Now,
+		recent: map[string][]time.Time{},
+	}
+
+	for _, sample := range rules.KnownSpam.Samples {
+		checker.knownSpam = append(checker.knownSpam, fingerprint.SimHash(sample))
+	}
+	for _, phrase := range rules.BlacklistedPhrases.Phrases {
+		if normalized := fingerprint.Normalize(phrase); normalized != "" {
+			checker.phrases = append(checker.phrases, normalized)
+		}
+	}
+
+	return checker
+}
+
+// Check scores content submitted by sender, an author name or client IP.
+// Every call counts towards the sender's submission rate.
+func (c *Checker) Check(content string, sender string) Verdict {
+	verdict := Verdict{}
+	add := func(weight float64, reason string) {
+		verdict.Score += weight
+		verdict.Reasons = append(verdict.Reasons, reason)
+	}
+
+	words := len(strings.Fields(content))
+	links := len(linkPattern.FindAllStringIndex(content, -1))
+	if links > 0 && words > 0 && float64(links)/float64(words) > c.Rules.Links.MaxDensity {
+		add(c.Rules.Links.Weight, fmt.Sprintf("%d links in %d words", links, words))
+	}
+
+	if run := longestRun(content); c.Rules.RepeatedCharacters.MinRun > 0 && run >= c.Rules.RepeatedCharacters.MinRun {
+		add(c.Rules.RepeatedCharacters.Weight, fmt.Sprintf("character repeated %d times", run))
+	}
+
+	normalized := " " + fingerprint.Normalize(content) + " "
+	for _, phrase := range c.phrases {
+		if strings.Contains(normalized, " "+phrase+" ") || (!isSpaced(phrase) && strings.Contains(normalized, phrase)) {
+			add(c.Rules.BlacklistedPhrases.Weight, fmt.Sprintf("blacklisted phrase %q", phrase))
+		}
+	}
+
+	if count := c.recordSubmission(sender); c.Rules.SubmissionRate.Max > 0 && count > c.Rules.SubmissionRate.Max {
+		add(c.Rules.SubmissionRate.Weight, fmt.Sprintf("%d submissions within %s", count, c.Rules.SubmissionRate.Window.Duration))
+	}
+
+	simHash := fingerprint.SimHash(content)
+	for _, known := range c.knownSpam {
+		if fingerprint.NearDuplicate(known, simHash) {
+			add(c.Rules.KnownSpam.Weight, "matches known spam")
+			break
+		}
+	}
+
+	verdict.Spam = verdict.Score >= c.Rules.Threshold
+	verdict.Reject = c.Rules.RejectThreshold > 0 && verdict.Score >= c.Rules.RejectThreshold
+
+	return verdict
+}
+
+// recordSubmission notes a submission from sender and returns how many it has
+// made within the rate window, including this one.
+func (c *Checker) recordSubmission(sender string) int {
+	if sender == "" || c.Rules.SubmissionRate.Window.Duration <= 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.Now()
+	cutoff := now.Add(-c.Rules.SubmissionRate.Window.Duration)
+
+	var kept []time.Time
+	for _, at := range c.recent[sender] {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	kept = append(kept, now)
+	c.recent[sender] = kept
+
+	// Drop senders that have gone quiet so the map doesn't grow forever.
+	for other, times := range c.recent {
+		if len(times) > 0 && !times[len(times)-1].After(cutoff) {
+			delete(c.recent, other)
+		}
+	}
+
+	return len(kept)
+}
+
+func longestRun(text string) int {
+	longest, run := 0, 0
+	var previous rune
+
+	for i, r := range []rune(text) {
+		if i > 0 && r == previous {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		previous = r
+	}
+
+	return longest
+}
+
+// isSpaced reports whether a phrase is made of space separated words. Thai
+// phrases are not, so they are matched anywhere in the text.
+func isSpaced(phrase string) bool {
+	for _, r := range phrase {
+		if r < 0x80 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package spam_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"food-review/pkg/spam"
+)
+
+func TestCheck(t *testing.T) {
+	checker := spam.NewChecker(spam.DefaultRules())
+
+	t.Run("Ordinary Review", func(t *testing.T) {
+		verdict := checker.Check("The pad thai was a little sweet but the portion was generous.", "")
+		assert.Zero(t, verdict.Score)
+		assert.False(t, verdict.Spam)
+	})
+
+	t.Run("Links and Phrases", func(t *testing.T) {
+		verdict := checker.Check("Click here http://cheap.example www.deals.xyz free money", "")
+		assert.True(t, verdict.Spam)
+		assert.Len(t, verdict.Reasons, 3)
+	})
+
+	t.Run("Thai Phrase", func(t *testing.T) {
+		verdict := checker.Check("อาหารอร่อย สมัครเว็บพนันวันนี้", "")
+		assert.True(t, verdict.Spam)
+	})
+
+	t.Run("Repeated Characters", func(t *testing.T) {
+		verdict := checker.Check("sooooooo good!!!!!!!", "")
+		assert.Equal(t, 1.0, verdict.Score)
+		assert.False(t, verdict.Spam)
+	})
+
+	t.Run("Known Spam", func(t *testing.T) {
+		verdict := checker.Check("Best deals on replica watches visit our website today for huge discounts!!", "")
+		assert.True(t, verdict.Spam)
+		assert.Contains(t, verdict.Reasons, "matches known spam")
+	})
+}
+
+func TestSubmissionRate(t *testing.T) {
+	checker := spam.NewChecker(spam.DefaultRules())
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	checker.Now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		assert.False(t, checker.Check("Nice noodles", "10.0.0.1").Spam)
+	}
+	assert.True(t, checker.Check("Nice noodles", "10.0.0.1").Spam)
+	assert.False(t, checker.Check("Nice noodles", "10.0.0.2").Spam)
+
+	now = now.Add(11 * time.Minute)
+	assert.False(t, checker.Check("Nice noodles", "10.0.0.1").Spam)
+}
+
+func TestRejectThreshold(t *testing.T) {
+	rules := spam.DefaultRules()
+	rules.RejectThreshold = 6
+	checker := spam.NewChecker(rules)
+
+	verdict := checker.Check("Buy now at http://a.example http://b.example casino", "")
+	assert.True(t, verdict.Reject)
+}
+
+func TestLoadRules(t *testing.T) {
+	t.Run("Missing File", func(t *testing.T) {
+		_, err := spam.LoadRules(filepath.Join(t.TempDir(), "spam.json"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("No File Uses Defaults", func(t *testing.T) {
+		rules, err := spam.LoadRules("")
+		if assert.NoError(t, err) {
+			assert.Equal(t, spam.DefaultRules().Threshold, rules.Threshold)
+		}
+	})
+
+	t.Run("From File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "spam.json")
+		content := `{"threshold": 1, "submission_rate": {"max": 2, "window": "1h", "weight": 1}}`
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		rules, err := spam.LoadRules(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1.0, rules.Threshold)
+			assert.Equal(t, time.Hour, rules.SubmissionRate.Window.Duration)
+		}
+	})
+
+	t.Run("Bad Window", func(t *testing.T) {
+		_, err := spam.ParseRules([]byte(`{"threshold": 1, "submission_rate": {"window": "soon"}}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("No Threshold", func(t *testing.T) {
+		_, err := spam.ParseRules([]byte(`{}`))
+		if assert.Error(t, err) {
+			assert.True(t, strings.Contains(err.Error(), "threshold"))
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"food-review/pkg/db"
+	"food-review/pkg/fingerprint"
+	"food-review/pkg/model"
+)
+
+// memoryTime is how the review database stores timestamps.
+const memoryTime = "2006-01-02 15:04:05"
+
+// openMemory opens a private in-memory SQLite database with migrations
+// applied. Every connection to ":memory:" is a database of its own, so the
+// pool is held to the one connection. It panics if the migrations fail,
+// which would be a bug in them.
+func openMemory(migrations []db.Migration) *sql.DB {
+	database, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		panic(err)
+	}
+	database.SetMaxOpenConns(1)
+	database.SetConnMaxLifetime(0)
+	database.SetConnMaxIdleTime(0)
+
+	if _, err := db.MigrateUp(database, migrations); err != nil {
+		panic(err)
+	}
+	return database
+}
+
+// MemoryReviewStore is a SQLiteReviewStore over a private in-memory database,
+// so it runs the same model functions as production. It is meant for tests.
+type MemoryReviewStore struct {
+	*SQLiteReviewStore
+}
+
+func NewMemoryReviewStore() *MemoryReviewStore {
+	return &MemoryReviewStore{NewSQLiteReviewStore(openMemory(db.ReviewMigrations))}
+}
+
+// Insert stores review as given, bypassing validation and moderation, and
+// returns its ID. A zero ID is assigned the next one, and zero timestamps
+// the current time. It panics if the insert fails.
+func (s *MemoryReviewStore) Insert(review model.Review) uint {
+	createdAt, updatedAt := review.CreatedAt, review.UpdatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	var reviewID interface{}
+	if review.ID != 0 {
+		reviewID = review.ID
+	}
+	if review.Sentiment == "" {
+		review.Sentiment = "neutral"
+	}
+
+	insertStatement := `INSERT INTO review (review_id, review, status, sentiment, sentiment_score, content_hash, simhash, created_at, updated_at, edited_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := s.DB.Exec(
+		insertStatement,
+		reviewID,
+		review.Content,
+		review.Status,
+		review.Sentiment,
+		review.SentimentScore,
+		fingerprint.ContentHash(review.Content),
+		int64(fingerprint.SimHash(review.Content)),
+		createdAt.UTC().Format(memoryTime),
+		updatedAt.UTC().Format(memoryTime),
+		review.EditedCount,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	insertedID, err := result.LastInsertId()
+	if err != nil {
+		panic(err)
+	}
+	return uint(insertedID)
+}
+
+// MemoryDictionaryStore is a SQLiteDictionaryStore over a private in-memory
+// database. It is meant for tests.
+type MemoryDictionaryStore struct {
+	*SQLiteDictionaryStore
+}
+
+// NewMemoryDictionaryStore returns a dictionary holding keywords. It panics
+// if one can't be added.
+func NewMemoryDictionaryStore(keywords ...string) *MemoryDictionaryStore {
+	database := openMemory(db.DictionaryMigrations)
+	for _, keyword := range keywords {
+		if _, err := database.Exec("INSERT INTO dictionary (keyword) VALUES (?)", keyword); err != nil {
+			panic(err)
+		}
+	}
+	return &MemoryDictionaryStore{NewSQLiteDictionaryStore(database)}
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"time"
+
+	"food-review/pkg/extract"
+	"food-review/pkg/model"
+)
+
+// Observer is called as a store call starts, with the store ("review" or
+// "dictionary") and method name, and returns the function to call with the
+// call's error once it finishes.
+type Observer func(store string, operation string) func(err error)
+
+// ObservedReviewStore reports every call to Next to Observe, e.g. to time
+// the queries behind it.
+type ObservedReviewStore struct {
+	Next    ReviewStore
+	Observe Observer
+}
+
+func NewObservedReviewStore(next ReviewStore, observe Observer) *ObservedReviewStore {
+	return &ObservedReviewStore{Next: next, Observe: observe}
+}
+
+// ObservedDictionaryStore is ObservedReviewStore for the dictionary.
+type ObservedDictionaryStore struct {
+	Next    DictionaryStore
+	Observe Observer
+}
+
+func NewObservedDictionaryStore(next DictionaryStore, observe Observer) *ObservedDictionaryStore {
+	return &ObservedDictionaryStore{Next: next, Observe: observe}
+}
+
+func (s *ObservedReviewStore) List() ([]*model.Review, error) {
+	done := s.Observe("review", "List")
+	reviews, err := s.Next.List()
+	done(err)
+	return reviews, err
+}
+
+func (s *ObservedReviewStore) Get(reviewID uint) (*model.Review, error) {
+	done := s.Observe("review", "Get")
+	review, err := s.Next.Get(reviewID)
+	done(err)
+	return review, err
+}
+
+func (s *ObservedReviewStore) Search(keyword string, sentimentLabel string) ([]*model.Review, error) {
+	done := s.Observe("review", "Search")
+	reviews, err := s.Next.Search(keyword, sentimentLabel)
+	done(err)
+	return reviews, err
+}
+
+func (s *ObservedReviewStore) Create(reviewBody []byte, flags []model.Flag) (*model.Review, error) {
+	done := s.Observe("review", "Create")
+	review, err := s.Next.Create(reviewBody, flags)
+	done(err)
+	return review, err
+}
+
+func (s *ObservedReviewStore) Update(reviewID uint, reviewBody []byte, flags []model.Flag) error {
+	done := s.Observe("review", "Update")
+	err := s.Next.Update(reviewID, reviewBody, flags)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) Delete(reviewID uint) error {
+	done := s.Observe("review", "Delete")
+	err := s.Next.Delete(reviewID)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) Flag(reviewID uint, flagBody []byte) error {
+	done := s.Observe("review", "Flag")
+	err := s.Next.Flag(reviewID, flagBody)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) Approve(reviewID uint, moderator string, noteBody []byte) error {
+	done := s.Observe("review", "Approve")
+	err := s.Next.Approve(reviewID, moderator, noteBody)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) Reject(reviewID uint, moderator string, noteBody []byte) error {
+	done := s.Observe("review", "Reject")
+	err := s.Next.Reject(reviewID, moderator, noteBody)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) Annotate(reviewID uint, moderator string, noteBody []byte) error {
+	done := s.Observe("review", "Annotate")
+	err := s.Next.Annotate(reviewID, moderator, noteBody)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) ModerationQueue() ([]*model.QueueItem, error) {
+	done := s.Observe("review", "ModerationQueue")
+	queue, err := s.Next.ModerationQueue()
+	done(err)
+	return queue, err
+}
+
+func (s *ObservedReviewStore) KeywordSentiments(keywords []string) ([]*model.KeywordSentiment, error) {
+	done := s.Observe("review", "KeywordSentiments")
+	breakdown, err := s.Next.KeywordSentiments(keywords)
+	done(err)
+	return breakdown, err
+}
+
+func (s *ObservedReviewStore) KeywordAspects(keyword string) (*model.AspectReport, error) {
+	done := s.Observe("review", "KeywordAspects")
+	report, err := s.Next.KeywordAspects(keyword)
+	done(err)
+	return report, err
+}
+
+func (s *ObservedReviewStore) KeywordCandidates(existing []string) ([]extract.Candidate, error) {
+	done := s.Observe("review", "KeywordCandidates")
+	candidates, err := s.Next.KeywordCandidates(existing)
+	done(err)
+	return candidates, err
+}
+
+func (s *ObservedReviewStore) RefreshKeywordMentions(reviewID uint, keywords []string) error {
+	done := s.Observe("review", "RefreshKeywordMentions")
+	err := s.Next.RefreshKeywordMentions(reviewID, keywords)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) IndexKeyword(keyword string) error {
+	done := s.Observe("review", "IndexKeyword")
+	err := s.Next.IndexKeyword(keyword)
+	done(err)
+	return err
+}
+
+func (s *ObservedReviewStore) Stats(days int, now time.Time) (*model.Stats, error) {
+	done := s.Observe("review", "Stats")
+	stats, err := s.Next.Stats(days, now)
+	done(err)
+	return stats, err
+}
+
+func (s *ObservedReviewStore) DuplicateClusters() ([]*model.DuplicateCluster, error) {
+	done := s.Observe("review", "DuplicateClusters")
+	clusters, err := s.Next.DuplicateClusters()
+	done(err)
+	return clusters, err
+}
+
+func (s *ObservedReviewStore) Merge(moderator string, mergeBody []byte) (uint, error) {
+	done := s.Observe("review", "Merge")
+	keptID, err := s.Next.Merge(moderator, mergeBody)
+	done(err)
+	return keptID, err
+}
+
+func (s *ObservedDictionaryStore) List() ([]string, error) {
+	done := s.Observe("dictionary", "List")
+	keywords, err := s.Next.List()
+	done(err)
+	return keywords, err
+}
+
+func (s *ObservedDictionaryStore) KeywordExists(keyword string) (bool, error) {
+	done := s.Observe("dictionary", "KeywordExists")
+	exist, err := s.Next.KeywordExists(keyword)
+	done(err)
+	return exist, err
+}
+
+func (s *ObservedDictionaryStore) Add(keywordBody []byte) (string, error) {
+	done := s.Observe("dictionary", "Add")
+	keyword, err := s.Next.Add(keywordBody)
+	done(err)
+	return keyword, err
+}
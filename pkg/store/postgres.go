@@ -0,0 +1,60 @@
+package store
+
+import (
+	"database/sql"
+	"unicode"
+
+	"food-review/pkg/model"
+)
+
+// PostgresReviewStore keeps reviews in Postgres. The model's statements run
+// on Postgres as well as SQLite, so it shares them with SQLiteReviewStore and
+// differs only in keyword search, which uses the review's tsvector instead of
+// scanning the text with LIKE. A keyword matches whole words, so "mango"
+// doesn't find "mangosteen" as it does in SQLite.
+type PostgresReviewStore struct {
+	*SQLiteReviewStore
+}
+
+func NewPostgresReviewStore(db *sql.DB) *PostgresReviewStore {
+	return &PostgresReviewStore{NewSQLiteReviewStore(db)}
+}
+
+// postgresKeyword matches reviews with keyword as a phrase of whole words.
+// The text search parser can't split Thai, which is written without spaces,
+// so Thai keywords fall back to a substring match.
+func postgresKeyword(keyword string, param string) string {
+	for _, r := range keyword {
+		if unicode.Is(unicode.Thai, r) {
+			return "strpos(lower(review), lower(" + param + ")) > 0"
+		}
+	}
+	return "search @@ phraseto_tsquery('simple', " + param + ")"
+}
+
+func (s *PostgresReviewStore) Search(keyword string, sentimentLabel string) ([]*model.Review, error) {
+	return model.GetReviewsByKeyword(s.DB, postgresKeyword, keyword, sentimentLabel)
+}
+
+func (s *PostgresReviewStore) KeywordSentiments(keywords []string) ([]*model.KeywordSentiment, error) {
+	return model.GetKeywordSentiments(s.DB, postgresKeyword, keywords)
+}
+
+func (s *PostgresReviewStore) KeywordAspects(keyword string) (*model.AspectReport, error) {
+	return model.GetKeywordAspects(s.DB, postgresKeyword, keyword)
+}
+
+func (s *PostgresReviewStore) IndexKeyword(keyword string) error {
+	return model.IndexKeyword(s.DB, postgresKeyword, keyword)
+}
+
+// PostgresDictionaryStore keeps keywords in the dictionary table of the same
+// Postgres database as the reviews, through the same model functions as
+// SQLiteDictionaryStore.
+type PostgresDictionaryStore struct {
+	*SQLiteDictionaryStore
+}
+
+func NewPostgresDictionaryStore(db *sql.DB) *PostgresDictionaryStore {
+	return &PostgresDictionaryStore{NewSQLiteDictionaryStore(db)}
+}
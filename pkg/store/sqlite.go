@@ -0,0 +1,123 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"food-review/pkg/extract"
+	"food-review/pkg/model"
+)
+
+// SQLiteReviewStore keeps reviews in the review database through the model
+// functions. Keyword search matches substrings, so "mango" finds
+// "mangosteen".
+type SQLiteReviewStore struct {
+	DB *sql.DB
+}
+
+func NewSQLiteReviewStore(db *sql.DB) *SQLiteReviewStore {
+	return &SQLiteReviewStore{DB: db}
+}
+
+func (s *SQLiteReviewStore) List() ([]*model.Review, error) {
+	return model.GetAllReviews(s.DB)
+}
+
+func (s *SQLiteReviewStore) Get(reviewID uint) (*model.Review, error) {
+	return model.GetReview(s.DB, reviewID)
+}
+
+func (s *SQLiteReviewStore) Search(keyword string, sentimentLabel string) ([]*model.Review, error) {
+	return model.GetReviewsByKeyword(s.DB, model.ContainsKeyword, keyword, sentimentLabel)
+}
+
+func (s *SQLiteReviewStore) Create(reviewBody []byte, flags []model.Flag) (*model.Review, error) {
+	return model.CreateReview(s.DB, reviewBody, flags)
+}
+
+func (s *SQLiteReviewStore) Update(reviewID uint, reviewBody []byte, flags []model.Flag) error {
+	return model.UpdateReview(s.DB, reviewID, reviewBody, flags)
+}
+
+func (s *SQLiteReviewStore) Delete(reviewID uint) error {
+	return model.DeleteReview(s.DB, reviewID)
+}
+
+func (s *SQLiteReviewStore) Flag(reviewID uint, flagBody []byte) error {
+	return model.FlagReview(s.DB, reviewID, flagBody)
+}
+
+func (s *SQLiteReviewStore) Approve(reviewID uint, moderator string, noteBody []byte) error {
+	return model.ApproveReview(s.DB, reviewID, moderator, noteBody)
+}
+
+func (s *SQLiteReviewStore) Reject(reviewID uint, moderator string, noteBody []byte) error {
+	return model.RejectReview(s.DB, reviewID, moderator, noteBody)
+}
+
+func (s *SQLiteReviewStore) Annotate(reviewID uint, moderator string, noteBody []byte) error {
+	return model.AnnotateReview(s.DB, reviewID, moderator, noteBody)
+}
+
+func (s *SQLiteReviewStore) ModerationQueue() ([]*model.QueueItem, error) {
+	return model.GetModerationQueue(s.DB)
+}
+
+func (s *SQLiteReviewStore) KeywordSentiments(keywords []string) ([]*model.KeywordSentiment, error) {
+	return model.GetKeywordSentiments(s.DB, model.ContainsKeyword, keywords)
+}
+
+func (s *SQLiteReviewStore) KeywordAspects(keyword string) (*model.AspectReport, error) {
+	return model.GetKeywordAspects(s.DB, model.ContainsKeyword, keyword)
+}
+
+func (s *SQLiteReviewStore) KeywordCandidates(existing []string) ([]extract.Candidate, error) {
+	return model.ScanKeywordCandidates(s.DB, existing)
+}
+
+func (s *SQLiteReviewStore) RefreshKeywordMentions(reviewID uint, keywords []string) error {
+	return model.RefreshKeywordMentions(s.DB, reviewID, keywords)
+}
+
+func (s *SQLiteReviewStore) IndexKeyword(keyword string) error {
+	return model.IndexKeyword(s.DB, model.ContainsKeyword, keyword)
+}
+
+func (s *SQLiteReviewStore) Stats(days int, now time.Time) (*model.Stats, error) {
+	return model.GetStats(s.DB, days, now)
+}
+
+func (s *SQLiteReviewStore) DuplicateClusters() ([]*model.DuplicateCluster, error) {
+	return model.GetDuplicateClusters(s.DB)
+}
+
+func (s *SQLiteReviewStore) Merge(moderator string, mergeBody []byte) (uint, error) {
+	return model.MergeReviews(s.DB, moderator, mergeBody)
+}
+
+// Backfill runs the backfills queued by migrations and returns their names.
+// keywords are the dictionary's.
+func (s *SQLiteReviewStore) Backfill(keywords []string) ([]string, error) {
+	return model.RunBackfills(s.DB, keywords)
+}
+
+// SQLiteDictionaryStore keeps keywords in the dictionary database.
+type SQLiteDictionaryStore struct {
+	DB *sql.DB
+}
+
+func NewSQLiteDictionaryStore(db *sql.DB) *SQLiteDictionaryStore {
+	return &SQLiteDictionaryStore{DB: db}
+}
+
+func (s *SQLiteDictionaryStore) List() ([]string, error) {
+	return model.GetAllKeywords(s.DB)
+}
+
+func (s *SQLiteDictionaryStore) KeywordExists(keyword string) (bool, error) {
+	return model.KeywordExists(s.DB, keyword)
+}
+
+func (s *SQLiteDictionaryStore) Add(keywordBody []byte) (string, error) {
+	return model.AddKeyword(s.DB, keywordBody)
+}
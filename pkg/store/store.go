@@ -0,0 +1,62 @@
+// Package store defines the storage the handlers depend on, with SQLite and
+// Postgres implementations for production and an in-memory one for tests.
+//
+// Every implementation reports a missing review as sql.ErrNoRows and
+// validation failures with the errors from package model, so handlers can map
+// them to status codes without knowing which store they talk to.
+package store
+
+import (
+	"time"
+
+	"food-review/pkg/extract"
+	"food-review/pkg/model"
+)
+
+type ReviewStore interface {
+	// List returns the approved reviews.
+	List() ([]*model.Review, error)
+	Get(reviewID uint) (*model.Review, error)
+	// Search returns approved reviews mentioning keyword, optionally with the
+	// given sentiment, or sql.ErrNoRows when there are none.
+	// Whether a keyword matches inside longer words depends on the store.
+	Search(keyword string, sentimentLabel string) ([]*model.Review, error)
+	// Create and Update raise flags, such as the handler's spam flags, on
+	// the review in the transaction that writes it.
+	Create(reviewBody []byte, flags []model.Flag) (*model.Review, error)
+	Update(reviewID uint, reviewBody []byte, flags []model.Flag) error
+	Delete(reviewID uint) error
+
+	ModerationStore
+	AnalyticsStore
+}
+
+type ModerationStore interface {
+	Flag(reviewID uint, flagBody []byte) error
+	// Approve, Reject and Annotate audit the action as moderator's.
+	Approve(reviewID uint, moderator string, noteBody []byte) error
+	Reject(reviewID uint, moderator string, noteBody []byte) error
+	Annotate(reviewID uint, moderator string, noteBody []byte) error
+	ModerationQueue() ([]*model.QueueItem, error)
+}
+
+type AnalyticsStore interface {
+	KeywordSentiments(keywords []string) ([]*model.KeywordSentiment, error)
+	KeywordAspects(keyword string) (*model.AspectReport, error)
+	KeywordCandidates(existing []string) ([]extract.Candidate, error)
+	RefreshKeywordMentions(reviewID uint, keywords []string) error
+	IndexKeyword(keyword string) error
+	Stats(days int, now time.Time) (*model.Stats, error)
+	DuplicateClusters() ([]*model.DuplicateCluster, error)
+	// Merge keeps the lowest of the review IDs in mergeBody, deletes the
+	// rest and returns the kept ID, auditing the merge as moderator's.
+	Merge(moderator string, mergeBody []byte) (uint, error)
+}
+
+type DictionaryStore interface {
+	// List returns every keyword in alphabetical order.
+	List() ([]string, error)
+	KeywordExists(keyword string) (bool, error)
+	// Add stores the keyword in keywordBody and returns it normalised.
+	Add(keywordBody []byte) (string, error)
+}
@@ -0,0 +1,526 @@
+package store_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/db"
+	"food-review/pkg/model"
+	"food-review/pkg/store"
+)
+
+// The conformance tests run against every implementation so they stay
+// interchangeable.
+type storeFactory func(t *testing.T) (store.ReviewStore, store.DictionaryStore)
+
+func sqliteStores(t *testing.T) (store.ReviewStore, store.DictionaryStore) {
+	dir := t.TempDir()
+	reviewDB := &db.ReviewDB{
+		Driver:     "sqlite3",
+		DataSource: filepath.Join(dir, "review.db"),
+		Migrations: db.ReviewMigrations,
+	}
+	dictionaryDB := &db.DictionaryDB{
+		Driver:     "sqlite3",
+		DataSource: filepath.Join(dir, "dictionary.db"),
+		Migrations: db.DictionaryMigrations,
+	}
+	require.NoError(t, reviewDB.Init())
+	require.NoError(t, dictionaryDB.Init())
+	t.Cleanup(func() {
+		reviewDB.GetDB().Close()
+		dictionaryDB.GetDB().Close()
+	})
+
+	return store.NewSQLiteReviewStore(reviewDB.GetDB()), store.NewSQLiteDictionaryStore(dictionaryDB.GetDB())
+}
+
+func memoryStores(t *testing.T) (store.ReviewStore, store.DictionaryStore) {
+	return store.NewMemoryReviewStore(), store.NewMemoryDictionaryStore()
+}
+
+// postgresDSNEnv names a Postgres database the conformance tests may wipe,
+// such as a local instance started with
+// docker run -e POSTGRES_PASSWORD=test -p 5432:5432 postgres.
+const postgresDSNEnv = "FOOD_REVIEW_TEST_POSTGRES_DSN"
+
+// postgresRequiredEnv is set in CI, which provides a database, so the
+// Postgres tests fail there instead of quietly skipping without one.
+const postgresRequiredEnv = "FOOD_REVIEW_REQUIRE_POSTGRES"
+
+// skipWithoutPostgres skips the test when there is no database to run it on.
+func skipWithoutPostgres(t *testing.T) {
+	t.Helper()
+	if os.Getenv(postgresDSNEnv) != "" {
+		return
+	}
+	if os.Getenv(postgresRequiredEnv) != "" {
+		t.Fatal(postgresDSNEnv + " is not set, but " + postgresRequiredEnv + " is")
+	}
+	t.Skip(postgresDSNEnv + " is not set")
+}
+
+// postgresDatabase reverts every migration of the test database and applies
+// them again, so each test starts with empty tables and fresh IDs.
+func postgresDatabase(t *testing.T) *sql.DB {
+	postgresDB := db.NewPostgresDB(os.Getenv(postgresDSNEnv))
+	require.NoError(t, postgresDB.Open())
+	database := postgresDB.GetDB()
+	t.Cleanup(func() { database.Close() })
+
+	for {
+		reverted, err := db.MigrateDown(database, postgresDB.Migrations)
+		require.NoError(t, err)
+		if reverted == nil {
+			break
+		}
+	}
+	_, err := db.MigrateUp(database, postgresDB.Migrations)
+	require.NoError(t, err)
+
+	return database
+}
+
+func postgresStores(t *testing.T) (store.ReviewStore, store.DictionaryStore) {
+	database := postgresDatabase(t)
+	return store.NewPostgresReviewStore(database), store.NewPostgresDictionaryStore(database)
+}
+
+// observedStores wraps the memory stores to check the wrappers pass every
+// call and result through.
+func observedStores(t *testing.T) (store.ReviewStore, store.DictionaryStore) {
+	observe := func(storeName string, operation string) func(err error) {
+		return func(err error) {}
+	}
+	reviews, dictionary := memoryStores(t)
+	return store.NewObservedReviewStore(reviews, observe), store.NewObservedDictionaryStore(dictionary, observe)
+}
+
+var factories = map[string]storeFactory{
+	"SQLite":   sqliteStores,
+	"Memory":   memoryStores,
+	"Observed": observedStores,
+	"Postgres": postgresStores,
+}
+
+func TestConformance(t *testing.T) {
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			if name == "Postgres" {
+				skipWithoutPostgres(t)
+			}
+			t.Run("Review Lifecycle", func(t *testing.T) { testReviewLifecycle(t, factory) })
+			t.Run("Search", func(t *testing.T) { testSearch(t, factory) })
+			t.Run("Search Matching", func(t *testing.T) { testSearchMatching(t, factory, name != "Postgres") })
+			t.Run("Duplicates", func(t *testing.T) { testDuplicates(t, factory) })
+			t.Run("Moderation", func(t *testing.T) { testModeration(t, factory) })
+			t.Run("Analytics", func(t *testing.T) { testAnalytics(t, factory) })
+			t.Run("Dictionary", func(t *testing.T) { testDictionary(t, factory) })
+		})
+	}
+}
+
+// backfillStore is a store whose database migrations can leave data for Go
+// to fill in.
+type backfillStore interface {
+	store.ReviewStore
+	Backfill(keywords []string) ([]string, error)
+}
+
+// legacyReviews were written before reviews had sentiments, keyword
+// mentions or fingerprints.
+var legacyReviews = []string{
+	"The khao soi here is rich and creamy with perfectly tender chicken, crispy noodles on top and a squeeze of lime.",
+	"The khao soi here is rich and creamy with perfectly tender chicken, crispy noodles on top and a squeeze of lime. Loved it",
+}
+
+func TestBackfill(t *testing.T) {
+	t.Run("SQLite", func(t *testing.T) {
+		reviewDB := db.NewReviewDB(filepath.Join(t.TempDir(), "review.db"))
+		require.NoError(t, reviewDB.Open())
+		database := reviewDB.GetDB()
+		t.Cleanup(func() { database.Close() })
+
+		_, err := db.MigrateUp(database, db.ReviewMigrations[:2])
+		require.NoError(t, err)
+		for _, content := range legacyReviews {
+			_, err := database.Exec("INSERT INTO review (review, status) VALUES (?, ?)", content, model.StatusApproved)
+			require.NoError(t, err)
+		}
+		_, err = db.MigrateUp(database, db.ReviewMigrations)
+		require.NoError(t, err)
+
+		testBackfill(t, store.NewSQLiteReviewStore(database))
+	})
+
+	t.Run("Postgres", func(t *testing.T) {
+		skipWithoutPostgres(t)
+		database := postgresDatabase(t)
+		for _, content := range legacyReviews {
+			_, err := database.Exec("INSERT INTO review (review, status) VALUES ($1, $2)", content, model.StatusApproved)
+			require.NoError(t, err)
+		}
+
+		testBackfill(t, store.NewPostgresReviewStore(database))
+	})
+}
+
+func testBackfill(t *testing.T, reviews backfillStore) {
+	ran, err := reviews.Backfill([]string{"khao soi", "lime"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.BackfillFingerprint, model.BackfillKeywordMention, model.BackfillSentiment}, ran)
+
+	clusters, err := reviews.DuplicateClusters()
+	require.NoError(t, err)
+	if assert.Len(t, clusters, 1) {
+		assert.Len(t, clusters[0].Reviews, 2)
+	}
+	_, err = reviews.Create([]byte(`{"review": "`+legacyReviews[0]+`"}`), nil)
+	assert.Equal(t, model.ErrDuplicateReview, err)
+
+	stats, err := reviews.Stats(7, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []model.KeywordCount{
+		{Keyword: "khao soi", Mentions: 2, Reviews: 2},
+		{Keyword: "lime", Mentions: 2, Reviews: 2},
+	}, stats.TopKeywords)
+
+	sentiments, err := reviews.KeywordSentiments([]string{"khao soi"})
+	require.NoError(t, err)
+	if assert.Len(t, sentiments, 1) {
+		assert.Equal(t, 2, sentiments[0].Positive, "legacy reviews are scored")
+	}
+
+	ran, err = reviews.Backfill(nil)
+	require.NoError(t, err)
+	assert.Empty(t, ran, "backfills run once")
+}
+
+// createApproved creates a review through the store and approves it.
+func createApproved(t *testing.T, reviews store.ReviewStore, content string) uint {
+	review, err := reviews.Create([]byte(`{"review": "`+content+`"}`), nil)
+	require.NoError(t, err)
+	require.NoError(t, reviews.Approve(review.ID, "anna", nil))
+	return review.ID
+}
+
+func testReviewLifecycle(t *testing.T, factory storeFactory) {
+	reviews, _ := factory(t)
+
+	created, err := reviews.Create([]byte(`{"review": "Lovely crispy pork belly", "status": "approved"}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPending, created.Status)
+	assert.Equal(t, "positive", created.Sentiment)
+	assert.WithinDuration(t, time.Now(), created.CreatedAt, time.Minute)
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+	assert.Zero(t, created.EditedCount)
+
+	_, err = reviews.Create([]byte(`{"review": "  "}`), nil)
+	assert.Equal(t, model.ErrEmptyReview, err)
+
+	got, err := reviews.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	listed, err := reviews.List()
+	require.NoError(t, err)
+	assert.Empty(t, listed, "pending reviews are not listed")
+
+	require.NoError(t, reviews.Approve(created.ID, "anna", nil))
+	listed, err = reviews.List()
+	require.NoError(t, err)
+	if assert.Len(t, listed, 1) {
+		assert.Equal(t, model.StatusApproved, listed[0].Status)
+	}
+
+	require.NoError(t, reviews.Update(created.ID, []byte(`{"review": "Soggy pork belly"}`), nil))
+	got, err = reviews.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Soggy pork belly", got.Content)
+	assert.Equal(t, model.StatusPending, got.Status, "edits need approval again")
+	assert.True(t, got.CreatedAt.Equal(created.CreatedAt), "edits keep the creation time")
+	assert.False(t, got.UpdatedAt.Before(created.UpdatedAt))
+	assert.Equal(t, 1, got.EditedCount)
+
+	require.NoError(t, reviews.Update(created.ID, []byte(`{"review": "Soggy pork belly, again", "edited_count": 40}`), nil))
+	got, err = reviews.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.EditedCount, "the count is kept by the store, not the client")
+
+	assert.Equal(t, sql.ErrNoRows, reviews.Update(999, []byte(`{"review": "Ghost"}`), nil))
+
+	require.NoError(t, reviews.Delete(created.ID))
+	_, err = reviews.Get(created.ID)
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Equal(t, sql.ErrNoRows, reviews.Delete(created.ID))
+}
+
+func testSearch(t *testing.T, factory storeFactory) {
+	reviews, _ := factory(t)
+
+	goodID := createApproved(t, reviews, "The Pad Thai was delicious")
+	createApproved(t, reviews, "Terrible pad thai, cold and bland")
+	_, err := reviews.Create([]byte(`{"review": "pad thai still waiting for approval"}`), nil)
+	require.NoError(t, err)
+
+	found, err := reviews.Search("pad thai", "")
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	found, err = reviews.Search("pad thai", "positive")
+	require.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, goodID, found[0].ID)
+		assert.Equal(t, "pad thai", found[0].Keyword)
+	}
+
+	_, err = reviews.Search("som tam", "")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	_, err = reviews.Search("x' OR '1'='1", "")
+	assert.Equal(t, sql.ErrNoRows, err, "keywords are matched as text, never as SQL")
+
+	_, err = reviews.Search("pad thai", "ecstatic")
+	assert.Equal(t, model.ErrInvalidSentiment, err)
+}
+
+// testSearchMatching pins down how the stores' keyword matching differs.
+// SQLite finds a keyword anywhere, even inside a longer word, while Postgres
+// matches whole words, except in Thai, which it can't split into words.
+func testSearchMatching(t *testing.T, factory storeFactory, substrings bool) {
+	reviews, _ := factory(t)
+
+	createApproved(t, reviews, "Lovely mangosteen, sweet and fresh")
+	createApproved(t, reviews, "The PAD THAI was great")
+	thaiID := createApproved(t, reviews, "ร้านนี้ผัดกุ้งอร่อยมาก")
+
+	found, err := reviews.Search("pad thai", "")
+	require.NoError(t, err)
+	assert.Len(t, found, 1, "case is ignored")
+
+	found, err = reviews.Search("ผัดกุ้ง", "")
+	require.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, thaiID, found[0].ID)
+	}
+
+	found, err = reviews.Search("mango", "")
+	sentiments, sentimentErr := reviews.KeywordSentiments([]string{"mango"})
+	require.NoError(t, sentimentErr)
+	if substrings {
+		require.NoError(t, err)
+		assert.Len(t, found, 1, "mango matches inside mangosteen")
+		assert.Equal(t, 1, sentiments[0].Total())
+	} else {
+		assert.Equal(t, sql.ErrNoRows, err, "mango is not the word mangosteen")
+		assert.Zero(t, sentiments[0].Total())
+	}
+}
+
+func testDuplicates(t *testing.T, factory storeFactory) {
+	reviews, _ := factory(t)
+
+	original := "The khao soi here is rich and creamy with perfectly tender chicken, crispy noodles on top and a squeeze of lime."
+	first := createApproved(t, reviews, original)
+
+	_, err := reviews.Create([]byte(`{"review": "`+original+`!!"}`), nil)
+	assert.Equal(t, model.ErrDuplicateReview, err)
+
+	similar, err := reviews.Create([]byte(`{"review": "`+original+` Loved it"}`), nil)
+	require.NoError(t, err)
+
+	queue, err := reviews.ModerationQueue()
+	require.NoError(t, err)
+	if assert.Len(t, queue, 1) && assert.Len(t, queue[0].Flags, 1) {
+		assert.Equal(t, model.FlagDuplicate, queue[0].Flags[0].Reason)
+		assert.Equal(t, model.SimilarNote([]uint{first}), queue[0].Flags[0].Note)
+	}
+
+	clusters, err := reviews.DuplicateClusters()
+	require.NoError(t, err)
+	if assert.Len(t, clusters, 1) && assert.Len(t, clusters[0].Reviews, 2) {
+		assert.Equal(t, first, clusters[0].Reviews[0].ID)
+		assert.Equal(t, similar.ID, clusters[0].Reviews[1].ID)
+	}
+
+	_, err = reviews.Merge("anna", []byte(`{"review_ids": [1]}`))
+	assert.Equal(t, model.ErrMergeTooFew, err)
+	_, err = reviews.Merge("anna", []byte(`{"review_ids": [1, 999]}`))
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	kept, err := reviews.Merge("anna", []byte(`{"review_ids": [`+itoa(similar.ID)+`, `+itoa(first)+`]}`))
+	require.NoError(t, err)
+	assert.Equal(t, first, kept)
+	_, err = reviews.Get(similar.ID)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func testModeration(t *testing.T, factory storeFactory) {
+	reviews, _ := factory(t)
+
+	reviewID := createApproved(t, reviews, "Great mango sticky rice")
+	profanityFlags := model.ProfanityFlags([]string{"shit"})
+	require.NoError(t, reviews.Update(reviewID, []byte(`{"review": "Great shit mango sticky rice"}`), profanityFlags))
+
+	assert.Equal(t, model.ErrInvalidFlagReason, reviews.Flag(reviewID, []byte(`{"reason": "boring"}`)))
+	assert.Equal(t, sql.ErrNoRows, reviews.Flag(999, []byte(`{"reason": "spam"}`)))
+	require.NoError(t, reviews.Flag(reviewID, []byte(`{"reason": "off-topic", "note": "about parking"}`)))
+	require.NoError(t, reviews.Annotate(reviewID, "somchai", []byte(`{"note": "checking"}`)))
+
+	queue, err := reviews.ModerationQueue()
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	item := queue[0]
+	assert.Equal(t, reviewID, item.Review.ID)
+	if assert.Len(t, item.Flags, 2) {
+		assert.Equal(t, model.FlagOffensive, item.Flags[0].Reason)
+		assert.Equal(t, "off-topic", item.Flags[1].Reason)
+	}
+	var actions []string
+	for _, entry := range item.Audit {
+		actions = append(actions, entry.Moderator+" "+entry.Action+" "+entry.Note)
+	}
+	assert.Equal(t, []string{
+		"anna approved ",
+		" flagged offensive: profanity: shit",
+		" flagged off-topic: about parking",
+		"somchai annotated checking",
+	}, actions)
+
+	require.NoError(t, reviews.Reject(reviewID, "anna", []byte(`{"note": "not about food"}`)))
+	queue, err = reviews.ModerationQueue()
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+
+	assert.Equal(t, sql.ErrNoRows, reviews.Approve(999, "anna", nil))
+
+	spamFlags := []model.Flag{{Reason: model.FlagSpam, Note: `blacklisted phrase "casino"`}}
+	flagged, err := reviews.Create([]byte(`{"review": "Nice soup, also try the casino upstairs"}`), spamFlags)
+	require.NoError(t, err)
+
+	queue, err = reviews.ModerationQueue()
+	require.NoError(t, err)
+	if assert.Len(t, queue, 1) && assert.Len(t, queue[0].Flags, 1) {
+		assert.Equal(t, flagged.ID, queue[0].Review.ID)
+		assert.Equal(t, model.FlagSpam, queue[0].Flags[0].Reason)
+	}
+}
+
+func testAnalytics(t *testing.T, factory storeFactory) {
+	reviews, _ := factory(t)
+	keywords := []string{"curry", "noodles", "satay"}
+
+	good := createApproved(t, reviews, "The curry was delicious and the noodles were great")
+	bad := createApproved(t, reviews, "Awful curry, terrible curry")
+	_, err := reviews.Create([]byte(`{"review": "Pending curry"}`), nil)
+	require.NoError(t, err)
+	for _, reviewID := range []uint{good, bad} {
+		require.NoError(t, reviews.RefreshKeywordMentions(reviewID, keywords))
+	}
+	assert.Equal(t, sql.ErrNoRows, reviews.RefreshKeywordMentions(999, keywords))
+
+	breakdown, err := reviews.KeywordSentiments(keywords)
+	require.NoError(t, err)
+	if assert.Len(t, breakdown, 2) {
+		assert.Equal(t, model.KeywordSentiment{Keyword: "curry", Positive: 1, Negative: 1}, *breakdown[0])
+		assert.Equal(t, model.KeywordSentiment{Keyword: "noodles", Positive: 1}, *breakdown[1])
+	}
+
+	report, err := reviews.KeywordAspects("curry")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Positive)
+	assert.Equal(t, 2, report.Negative)
+
+	report, err = reviews.KeywordAspects("satay")
+	require.NoError(t, err)
+	assert.Zero(t, report.Positive+report.Negative+report.Neutral)
+
+	stats, err := reviews.Stats(7, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalReviews)
+	if assert.Len(t, stats.TopKeywords, 2) {
+		assert.Equal(t, model.KeywordCount{Keyword: "curry", Mentions: 3, Reviews: 2}, stats.TopKeywords[0])
+		assert.Equal(t, model.KeywordCount{Keyword: "noodles", Mentions: 1, Reviews: 1}, stats.TopKeywords[1])
+	}
+	if assert.Len(t, stats.Trending, 2) {
+		assert.Equal(t, model.KeywordTrend{Keyword: "curry", Recent: 3}, stats.Trending[0])
+	}
+	if assert.Len(t, stats.ReviewsPerDay, 1) {
+		assert.Equal(t, 2, stats.ReviewsPerDay[0].Count)
+	}
+	if assert.NotNil(t, stats.Longest) && assert.NotNil(t, stats.Shortest) {
+		assert.Equal(t, good, stats.Longest.ID)
+		assert.Equal(t, bad, stats.Shortest.ID)
+	}
+
+	require.NoError(t, reviews.IndexKeyword("delicious"))
+	stats, err = reviews.Stats(7, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, stats.TopKeywords, 3)
+
+	createApproved(t, reviews, "Grilled pork skewers and grilled pork rice")
+	createApproved(t, reviews, "Grilled pork skewers were smoky")
+	candidates, err := reviews.KeywordCandidates(keywords)
+	require.NoError(t, err)
+	var terms []string
+	for _, candidate := range candidates {
+		terms = append(terms, candidate.Term)
+	}
+	assert.Contains(t, terms, "grilled pork")
+}
+
+func testDictionary(t *testing.T, factory storeFactory) {
+	_, dictionary := factory(t)
+
+	keyword, err := dictionary.Add([]byte(`{"keyword": "  Pad   THAI "}`))
+	require.NoError(t, err)
+	assert.Equal(t, "pad thai", keyword)
+
+	_, err = dictionary.Add([]byte(`{"keyword": "pad thai"}`))
+	assert.Equal(t, model.ErrKeywordExists, err)
+	_, err = dictionary.Add([]byte(`{"keyword": ""}`))
+	assert.Equal(t, model.ErrEmptyKeyword, err)
+
+	_, err = dictionary.Add([]byte(`{"keyword": "curry"}`))
+	require.NoError(t, err)
+
+	keywords, err := dictionary.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"curry", "pad thai"}, keywords)
+
+	exist, err := dictionary.KeywordExists("curry")
+	require.NoError(t, err)
+	assert.True(t, exist)
+	exist, err = dictionary.KeywordExists("satay")
+	require.NoError(t, err)
+	assert.False(t, exist)
+}
+
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func TestObservedStore(t *testing.T) {
+	var calls []string
+	observe := func(storeName string, operation string) func(err error) {
+		return func(err error) {
+			calls = append(calls, storeName+"."+operation+" "+strconv.FormatBool(err != nil))
+		}
+	}
+	reviews := store.NewObservedReviewStore(store.NewMemoryReviewStore(), observe)
+	dictionary := store.NewObservedDictionaryStore(store.NewMemoryDictionaryStore("curry"), observe)
+
+	_, err := reviews.Get(1)
+	assert.Equal(t, sql.ErrNoRows, err)
+	_, err = dictionary.KeywordExists("curry")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"review.Get true", "dictionary.KeywordExists false"}, calls)
+}
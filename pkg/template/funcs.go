@@ -0,0 +1,59 @@
+package template
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+
+	"food-review/pkg/i18n"
+)
+
+// localeFuncs are the template functions bound to a locale:
+//
+//	{{ T "Page %d of %d" .Page .Pages }}  translated text
+//	{{ date .CreatedAt }}                 a time.Time, or a day as "2006-01-02"
+//	{{ datetime .CreatedAt }}             a time.Time to the minute
+//	{{ ago .UpdatedAt }}                  a time.Time relative to now, "2 days ago"
+//	{{ number .Score }}                   integers grouped, floats to 2 places
+//	{{ locale }}                          the locale, such as "th"
+//	{{ locales }}                         every locale, for a language switcher
+//	{{ localeName "th" }}                 a locale's name in its own language
+func localeFuncs(catalog *i18n.Catalog, locale string) template.FuncMap {
+	printer := catalog.Printer(locale)
+	return template.FuncMap{
+		"T": printer.T,
+		"date": func(value interface{}) (string, error) {
+			switch v := value.(type) {
+			case time.Time:
+				return printer.Date(v), nil
+			case string:
+				day, err := time.Parse("2006-01-02", v)
+				if err != nil {
+					return "", err
+				}
+				return printer.Date(day), nil
+			}
+			return "", fmt.Errorf("date: unsupported type %T", value)
+		},
+		"datetime": printer.DateTime,
+		"ago": func(t time.Time) string {
+			return printer.Ago(t, time.Now())
+		},
+		"number": func(value interface{}) (string, error) {
+			switch v := value.(type) {
+			case int:
+				return printer.Int(int64(v)), nil
+			case int64:
+				return printer.Int(v), nil
+			case uint:
+				return printer.Int(int64(v)), nil
+			case float64:
+				return printer.Number(v, 2), nil
+			}
+			return "", fmt.Errorf("number: unsupported type %T", value)
+		},
+		"locale":     func() string { return printer.Locale },
+		"locales":    catalog.Locales,
+		"localeName": catalog.Name,
+	}
+}
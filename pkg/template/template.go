@@ -1,8 +1,26 @@
+// Package template renders HTML pages. Each page is parsed into a set of its
+// own together with the shared layouts and partials, so pages can fill in the
+// same blocks of a layout, such as "title" and "content", without their
+// definitions colliding.
+//
+// Pages are parsed once per locale of the Catalog, with the T, date, number
+// and locale functions bound to that locale. A page is rendered in the
+// language named by the response's Content-Language header, which the
+// request's locale negotiation sets, so handlers don't pass the locale along.
 package template
 
 import (
+	"fmt"
 	"html/template"
+	"io/fs"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"food-review/pkg/i18n"
 )
 
 type Templater interface {
@@ -10,22 +28,190 @@ type Templater interface {
 }
 
 type Template struct {
-	Tmpl *template.Template
+	// Funcs are made available to every template. They must be set before
+	// LoadTemplates.
+	Funcs template.FuncMap
+	// Reload re-parses the templates before executing one if any file has
+	// changed since they were loaded. It is meant for development, when the
+	// templates are read from disk.
+	Reload bool
+	// Catalog translates the pages. Without one they are only rendered in
+	// English.
+	Catalog *i18n.Catalog
+
+	mu      sync.Mutex
+	fsys    fs.FS
+	pattern string
+	shared  []string
+	// pages maps each locale, then each page's file name, to its template
+	// set.
+	pages map[string]map[string]*template.Template
+	// version is the newest modification time and the number of template
+	// files as of the last load.
+	version templateVersion
+}
+
+type templateVersion struct {
+	modified time.Time
+	files    int
 }
 
+// LoadTemplates parses the pages on disk matching pattern.
 func (t *Template) LoadTemplates(pattern string) error {
-	if templates, err := template.ParseGlob(pattern); err != nil {
+	dir, base := filepath.Split(pattern)
+	if dir == "" {
+		dir = "."
+	}
+	return t.LoadTemplatesFS(os.DirFS(dir), base)
+}
+
+// LoadTemplatesFS parses the pages in fsys matching pattern, such as the
+// templates embedded in the binary. Every page can use the templates in the
+// files matching the shared patterns: layouts it fills in and partials it
+// includes. Shared files are parsed before the page, so a page's definitions
+// take the place of a layout's default blocks.
+func (t *Template) LoadTemplatesFS(fsys fs.FS, pattern string, shared ...string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.fsys, t.pattern, t.shared = fsys, pattern, shared
+	return t.load()
+}
+
+func (t *Template) load() error {
+	pageNames, sharedNames, err := t.files()
+	if err != nil {
 		return err
-	} else {
-		t.Tmpl = templates
-		return nil
 	}
+	if len(pageNames) == 0 {
+		return fmt.Errorf("template: pattern matches no files: %#q", t.pattern)
+	}
+	version, err := t.currentVersion(append(pageNames, sharedNames...))
+	if err != nil {
+		return err
+	}
+
+	pages := map[string]map[string]*template.Template{}
+	for _, locale := range t.Catalog.Locales() {
+		localeFuncs := localeFuncs(t.Catalog, locale)
+		pages[locale] = map[string]*template.Template{}
+		for _, pageName := range pageNames {
+			name := path.Base(pageName)
+			set := template.New(name).Funcs(t.Funcs).Funcs(localeFuncs)
+			if len(sharedNames) > 0 {
+				if _, err := set.ParseFS(t.fsys, sharedNames...); err != nil {
+					return err
+				}
+			}
+			if _, err := set.ParseFS(t.fsys, pageName); err != nil {
+				return err
+			}
+			pages[locale][name] = set
+		}
+	}
+
+	t.pages = pages
+	t.version = version
+	return nil
+}
+
+// files lists the pages and the shared files, leaving out any page that is
+// also shared.
+func (t *Template) files() ([]string, []string, error) {
+	var sharedNames []string
+	isShared := map[string]bool{}
+	for _, pattern := range t.shared {
+		names, err := fs.Glob(t.fsys, pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, name := range names {
+			isShared[name] = true
+		}
+		sharedNames = append(sharedNames, names...)
+	}
+
+	names, err := fs.Glob(t.fsys, t.pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pageNames []string
+	for _, name := range names {
+		if !isShared[name] {
+			pageNames = append(pageNames, name)
+		}
+	}
+
+	return pageNames, sharedNames, nil
 }
 
+func (t *Template) currentVersion(names []string) (templateVersion, error) {
+	version := templateVersion{files: len(names)}
+	for _, name := range names {
+		info, err := fs.Stat(t.fsys, name)
+		if err != nil {
+			return templateVersion{}, err
+		}
+		if info.ModTime().After(version.modified) {
+			version.modified = info.ModTime()
+		}
+	}
+	return version, nil
+}
+
+// Loaded reports whether LoadTemplates has succeeded.
+func (t *Template) Loaded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pages != nil
+}
+
+// ExecuteTemplate renders the page in the file called template, e.g.
+// "reviews.html", in the locale of w's Content-Language header, or the
+// default locale.
 func (t *Template) ExecuteTemplate(w http.ResponseWriter, template string, data interface{}) error {
-	err := t.Tmpl.ExecuteTemplate(w, template, data)
+	pages, err := t.current()
+	if err != nil {
+		return err
+	}
+
+	localized, ok := pages[w.Header().Get("Content-Language")]
+	if !ok {
+		localized = pages[i18n.DefaultLocale]
+	}
+	page, ok := localized[template]
+	if !ok {
+		return fmt.Errorf("template: %q is undefined", template)
+	}
+
+	err = page.ExecuteTemplate(w, template, data)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// current returns the parsed pages, first reloading them if Reload is set
+// and they have changed.
+func (t *Template) current() (map[string]map[string]*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.Reload && t.fsys != nil {
+		pageNames, sharedNames, err := t.files()
+		if err != nil {
+			return nil, err
+		}
+		version, err := t.currentVersion(append(pageNames, sharedNames...))
+		if err != nil {
+			return nil, err
+		}
+		if version.files != t.version.files || !version.modified.Equal(t.version.modified) {
+			if err := t.load(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return t.pages, nil
+}
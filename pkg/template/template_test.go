@@ -1,12 +1,18 @@
 package template_test
 
 import (
+	htmltemplate "html/template"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"food-review/pkg/i18n"
 	"food-review/pkg/template"
 )
 
@@ -75,7 +81,7 @@ func TestLoadTemplates(t *testing.T) {
 		pattern := tempDir + "/*.html"
 
 		err := testTemplate.LoadTemplates(pattern)
-		if assert.NoError(t, err) && assert.NotNil(t, testTemplate.Tmpl) {
+		if assert.NoError(t, err) && assert.True(t, testTemplate.Loaded()) {
 			if err := os.RemoveAll(tempDir); err != nil {
 				t.Error(err)
 			}
@@ -131,4 +137,131 @@ func TestExecuteTemplate(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("With Funcs", func(t *testing.T) {
+		tempDir := "templatefolder1111"
+		if err := os.Mkdir(tempDir, os.ModePerm); err != nil {
+			t.Error(err)
+		}
+		if err := os.WriteFile(tempDir+"/index.html", []byte(`{{ shout . }}`), 0o644); err != nil {
+			t.Error(err)
+		}
+
+		testTemplate := &template.Template{
+			Funcs: htmltemplate.FuncMap{"shout": strings.ToUpper},
+		}
+		pattern := tempDir + "/*.html"
+		_ = testTemplate.LoadTemplates(pattern)
+
+		w := httptest.NewRecorder()
+		err := testTemplate.ExecuteTemplate(w, "index.html", "pad thai")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "PAD THAI", w.Body.String())
+			if err := os.RemoveAll(tempDir); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(page, []byte("before"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(testTemplate *template.Template) string {
+		w := httptest.NewRecorder()
+		if err := testTemplate.ExecuteTemplate(w, "index.html", nil); err != nil {
+			t.Fatal(err)
+		}
+		return w.Body.String()
+	}
+
+	reloading := &template.Template{Reload: true}
+	fixed := &template.Template{}
+	for _, testTemplate := range []*template.Template{reloading, fixed} {
+		if err := testTemplate.LoadTemplatesFS(os.DirFS(dir), "*.html"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(page, []byte("after"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(page, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "after", render(reloading))
+	assert.Equal(t, "before", render(fixed))
+}
+
+func TestLayouts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout/base.html":   {Data: []byte(`{{ define "base" }}<title>{{ block "title" . }}Food review{{ end }}</title>{{ block "content" . }}{{ end }}{{ end }}`)},
+		"partials/card.html": {Data: []byte(`{{ define "card" }}[{{ . }}]{{ end }}`)},
+		"reviews.html":       {Data: []byte(`{{ template "base" . }}{{ define "title" }}Reviews{{ end }}{{ define "content" }}{{ range . }}{{ template "card" . }}{{ end }}{{ end }}`)},
+		"stats.html":         {Data: []byte(`{{ template "base" . }}{{ define "content" }}{{ len . }} reviews{{ end }}`)},
+	}
+
+	testTemplate := &template.Template{}
+	err := testTemplate.LoadTemplatesFS(fsys, "*.html", "layout/*.html", "partials/*.html")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w := httptest.NewRecorder()
+	if assert.NoError(t, testTemplate.ExecuteTemplate(w, "reviews.html", []string{"pad thai", "som tam"})) {
+		assert.Equal(t, "<title>Reviews</title>[pad thai][som tam]", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	if assert.NoError(t, testTemplate.ExecuteTemplate(w, "stats.html", []string{"pad thai"})) {
+		assert.Equal(t, "<title>Food review</title>1 reviews", w.Body.String(), "blocks from other pages don't leak in")
+	}
+
+	w = httptest.NewRecorder()
+	err = testTemplate.ExecuteTemplate(w, "base.html", nil)
+	assert.ErrorContains(t, err, "is undefined", "layouts aren't pages")
+}
+
+func TestLocales(t *testing.T) {
+	catalog, err := i18n.LoadCatalogFS(fstest.MapFS{
+		"en.json": {Data: []byte(`{"name": "English", "messages": {}}`)},
+		"th.json": {Data: []byte(`{"name": "ไทย", "messages": {"Total reviews: %s": "รีวิวทั้งหมด: %s"}}`)},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	fsys := fstest.MapFS{
+		"stats.html": {Data: []byte(`<html lang="{{ locale }}">{{ T "Total reviews: %s" (number .Total) }} {{ date .Day }}</html>`)},
+	}
+
+	testTemplate := &template.Template{Catalog: catalog}
+	if !assert.NoError(t, testTemplate.LoadTemplatesFS(fsys, "*.html")) {
+		return
+	}
+	data := struct {
+		Total int
+		Day   string
+	}{12345, "2026-01-02"}
+
+	render := func(contentLanguage string) string {
+		w := httptest.NewRecorder()
+		if contentLanguage != "" {
+			w.Header().Set("Content-Language", contentLanguage)
+		}
+		if err := testTemplate.ExecuteTemplate(w, "stats.html", data); err != nil {
+			t.Fatal(err)
+		}
+		return w.Body.String()
+	}
+
+	assert.Equal(t, `<html lang="th">รีวิวทั้งหมด: 12,345 2 ม.ค. 2569</html>`, render("th"))
+	assert.Equal(t, `<html lang="en">Total reviews: 12,345 Jan 2, 2026</html>`, render("en"))
+	assert.Equal(t, `<html lang="en">Total reviews: 12,345 Jan 2, 2026</html>`, render(""), "no language renders the default")
 }
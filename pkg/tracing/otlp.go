@@ -0,0 +1,158 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OTLPJSONExporter writes each span as one line of OTLP/JSON, the format of
+// the OpenTelemetry collector's file exporter, so a collector's
+// otlpjsonfile receiver or any OTLP/JSON tool can read the output.
+type OTLPJSONExporter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	resource otlpResource
+}
+
+// NewOTLPJSONExporter writes spans to w on behalf of the named service.
+func NewOTLPJSONExporter(w io.Writer, serviceName string) *OTLPJSONExporter {
+	return &OTLPJSONExporter{
+		w: w,
+		resource: otlpResource{
+			Attributes: otlpAttributes([]Attribute{{"service.name", serviceName}}),
+		},
+	}
+}
+
+func (e *OTLPJSONExporter) Export(span *SpanData) error {
+	line, err := json.Marshal(otlpTraces{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: e.resource,
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "food-review"},
+				Spans: []otlpSpan{newOTLPSpan(span)},
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+// otlpSpan follows the OTLP/JSON mapping: IDs in hex and 64-bit integers as
+// strings.
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	// Code is 0 for unset and 2 for error.
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newOTLPSpan(span *SpanData) otlpSpan {
+	out := otlpSpan{
+		TraceID:           span.Context.TraceID.String(),
+		SpanID:            span.Context.SpanID.String(),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: unixNano(span.Start),
+		EndTimeUnixNano:   unixNano(span.End),
+		Attributes:        otlpAttributes(span.Attributes),
+	}
+	if span.Parent != (SpanID{}) {
+		out.ParentSpanID = span.Parent.String()
+	}
+	if span.Err != nil {
+		out.Status = otlpStatus{Code: 2, Message: span.Err.Error()}
+	}
+	return out
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpAttributes(attributes []Attribute) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attributes))
+	for _, attribute := range attributes {
+		out = append(out, otlpKeyValue{Key: attribute.Key, Value: newOTLPValue(attribute.Value)})
+	}
+	return out
+}
+
+func newOTLPValue(value interface{}) otlpValue {
+	integer := func(s string) otlpValue { return otlpValue{IntValue: &s} }
+	switch v := value.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		return integer(strconv.Itoa(v))
+	case int64:
+		return integer(strconv.FormatInt(v, 10))
+	case uint:
+		return integer(strconv.FormatUint(uint64(v), 10))
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case error:
+		s := v.Error()
+		return otlpValue{StringValue: &s}
+	default:
+		s := fmt.Sprint(v)
+		return otlpValue{StringValue: &s}
+	}
+}
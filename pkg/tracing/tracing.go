@@ -0,0 +1,291 @@
+// Package tracing records spans in OpenTelemetry's model: a trace of timed,
+// nested operations, joined across services with the W3C traceparent header.
+// Finished spans go to an Exporter, such as OTLP/JSON lines on stdout or in a
+// file that an OpenTelemetry collector can read.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext identifies a span within its trace. It is what crosses process
+// boundaries in the traceparent header.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent reads a traceparent header value. Versions after 00 are
+// read as far as version 00 goes, as the W3C spec asks.
+func ParseTraceparent(header string) (SpanContext, error) {
+	var sc SpanContext
+	if len(header) < 55 || (len(header) > 55 && header[55] != '-') {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+
+	version, err := decodeHex(header[0:2], 1)
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(header) != 55) {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+	traceID, err := decodeHex(header[3:35], 16)
+	if err != nil {
+		return sc, fmt.Errorf("invalid traceparent %q: %w", header, err)
+	}
+	spanID, err := decodeHex(header[36:52], 8)
+	if err != nil {
+		return sc, fmt.Errorf("invalid traceparent %q: %w", header, err)
+	}
+	flags, err := decodeHex(header[53:55], 1)
+	if err != nil {
+		return sc, fmt.Errorf("invalid traceparent %q: %w", header, err)
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: zero ID", header)
+	}
+
+	return sc, nil
+}
+
+// decodeHex decodes lower-case hex only; the spec doesn't allow upper case.
+func decodeHex(s string, size int) ([]byte, error) {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return nil, errors.New("not lower-case hex")
+		}
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != size {
+		return nil, errors.New("wrong length")
+	}
+	return b, nil
+}
+
+type SpanKind int
+
+// The kinds use OTLP's numbering.
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+)
+
+// Exporter receives each sampled span once it has ended.
+type Exporter interface {
+	Export(span *SpanData) error
+}
+
+// SpanData is a finished span.
+type SpanData struct {
+	Context SpanContext
+	// Parent is zero for the root span of a trace.
+	Parent     SpanID
+	Name       string
+	Kind       SpanKind
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	// Err is set when the operation failed.
+	Err error
+}
+
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts the spans of this service and hands them to its exporter.
+type Tracer struct {
+	exporter Exporter
+	// OnError is told when the exporter fails; it may be nil.
+	OnError func(err error)
+	// Now timestamps spans; tests replace it.
+	Now func() time.Time
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter, Now: time.Now}
+}
+
+// StartServer starts the span for an incoming request, continuing the trace
+// in remote when it is valid and starting a new, sampled one otherwise.
+func (t *Tracer) StartServer(ctx context.Context, name string, remote SpanContext) (context.Context, *Span) {
+	if !remote.IsValid() {
+		remote = SpanContext{TraceID: newTraceID(), Sampled: true}
+	}
+	return t.start(ctx, name, SpanKindServer, remote)
+}
+
+// Start starts a child of the span in ctx, or a new trace when there is none.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanContext{TraceID: newTraceID(), Sampled: true}
+	if span := SpanFromContext(ctx); span != nil {
+		parent = span.data.Context
+	}
+	return t.start(ctx, name, SpanKindInternal, parent)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind SpanKind, parent SpanContext) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Context: SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled},
+			Parent:  parent.SpanID,
+			Name:    name,
+			Kind:    kind,
+			Start:   t.Now(),
+		},
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start starts a child of the span in ctx using that span's tracer. Without
+// a span in ctx nothing is traced and the returned span is nil, which is
+// safe to use.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name)
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span in ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Span is an operation in progress. Its methods do nothing on a nil span, so
+// callers needn't check whether tracing is on.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// SetName renames the span, e.g. once the route it serves is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttributes adds the key/value pairs in args, in the same form as the
+// logging package takes them.
+func (s *Span) SetAttributes(args ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(args) > 0 {
+		if key, ok := args[0].(string); ok && len(args) > 1 {
+			s.data.Attributes = append(s.data.Attributes, Attribute{key, args[1]})
+			args = args[2:]
+		} else {
+			s.data.Attributes = append(s.data.Attributes, Attribute{"!BADKEY", args[0]})
+			args = args[1:]
+		}
+	}
+}
+
+// RecordError marks the span as failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Err = err
+}
+
+// End finishes the span and exports it if it is sampled. Only the first call
+// has any effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if !data.Context.Sampled {
+		return
+	}
+	if err := s.tracer.exporter.Export(&data); err != nil && s.tracer.OnError != nil {
+		s.tracer.OnError(err)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for id == (TraceID{}) {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for id == (SpanID{}) {
+		rand.Read(id[:])
+	}
+	return id
+}
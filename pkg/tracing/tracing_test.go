@@ -0,0 +1,159 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/tracing"
+)
+
+type recordingExporter struct {
+	spans []*tracing.SpanData
+}
+
+func (e *recordingExporter) Export(span *tracing.SpanData) error {
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+func TestParseTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := tracing.ParseTraceparent(header)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+		assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+		assert.True(t, sc.Sampled)
+		assert.Equal(t, header, sc.Traceparent())
+	}
+
+	sc, err = tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	if assert.NoError(t, err, "later versions may append fields") {
+		assert.False(t, sc.Sampled)
+	}
+
+	for name, header := range map[string]string{
+		"Empty":          "",
+		"Upper Case":     "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"Zero Trace ID":  "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"Zero Span ID":   "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"Version ff":     "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"Trailing Data":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"Bad Separators": "00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+	} {
+		_, err := tracing.ParseTraceparent(header)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	t.Run("Continues Remote Trace", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tracer := tracing.NewTracer(exporter)
+		remote, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		require.NoError(t, err)
+
+		ctx, server := tracer.StartServer(context.Background(), "GET", remote)
+		server.SetName("GET /reviews")
+		_, child := tracing.Start(ctx, "review.Search")
+		child.RecordError(errors.New("no such table"))
+		child.End()
+		server.End()
+		server.End()
+
+		require.Len(t, exporter.spans, 2)
+		search, request := exporter.spans[0], exporter.spans[1]
+		assert.Equal(t, "GET /reviews", request.Name)
+		assert.Equal(t, tracing.SpanKindServer, request.Kind)
+		assert.Equal(t, remote.TraceID, request.Context.TraceID)
+		assert.Equal(t, remote.SpanID, request.Parent)
+		assert.Equal(t, remote.TraceID, search.Context.TraceID)
+		assert.Equal(t, request.Context.SpanID, search.Parent)
+		assert.EqualError(t, search.Err, "no such table")
+	})
+
+	t.Run("Unsampled Remote Trace", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tracer := tracing.NewTracer(exporter)
+		remote, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		require.NoError(t, err)
+
+		ctx, server := tracer.StartServer(context.Background(), "GET", remote)
+		_, child := tracing.Start(ctx, "review.List")
+		child.End()
+		server.End()
+
+		assert.Empty(t, exporter.spans)
+	})
+
+	t.Run("New Trace", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tracer := tracing.NewTracer(exporter)
+
+		_, span := tracer.StartServer(context.Background(), "GET", tracing.SpanContext{})
+		span.End()
+
+		require.Len(t, exporter.spans, 1)
+		assert.True(t, exporter.spans[0].Context.IsValid())
+		assert.Equal(t, tracing.SpanID{}, exporter.spans[0].Parent)
+	})
+
+	t.Run("No Span In Context", func(t *testing.T) {
+		ctx, span := tracing.Start(context.Background(), "review.List")
+
+		assert.Nil(t, span)
+		assert.Nil(t, tracing.SpanFromContext(ctx))
+		assert.NotPanics(t, func() {
+			span.SetAttributes("store", "review")
+			span.RecordError(errors.New("failed"))
+			span.End()
+		})
+	})
+}
+
+func TestOTLPJSONExporter(t *testing.T) {
+	var out bytes.Buffer
+	tracer := tracing.NewTracer(tracing.NewOTLPJSONExporter(&out, "food-review"))
+	start := time.Unix(1700000000, 0)
+	tracer.Now = func() time.Time { return start }
+	remote, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	_, span := tracer.StartServer(context.Background(), "GET /reviews", remote)
+	span.SetAttributes("http.status_code", 500, "http.route", "/reviews", "dangling")
+	span.RecordError(errors.New("500 Internal Server Error"))
+	span.End()
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	resourceSpans := line["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "food-review"}},
+		},
+	}, resourceSpans["resource"])
+
+	scopeSpans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"traceId":           "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId":            span.SpanContext().SpanID.String(),
+		"parentSpanId":      "00f067aa0ba902b7",
+		"name":              "GET /reviews",
+		"kind":              float64(2),
+		"startTimeUnixNano": "1700000000000000000",
+		"endTimeUnixNano":   "1700000000000000000",
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "http.status_code", "value": map[string]interface{}{"intValue": "500"}},
+			map[string]interface{}{"key": "http.route", "value": map[string]interface{}{"stringValue": "/reviews"}},
+			map[string]interface{}{"key": "!BADKEY", "value": map[string]interface{}{"stringValue": "dangling"}},
+		},
+		"status": map[string]interface{}{"code": float64(2), "message": "500 Internal Server Error"},
+	}, scopeSpans["spans"].([]interface{})[0])
+}
@@ -0,0 +1,48 @@
+// Package version describes the running binary.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Commit and BuildTime are injected at build time, e.g.
+//
+//	go build -ldflags "-X food-review/pkg/version.Commit=$(git rev-parse HEAD)"
+var (
+	Commit    string
+	BuildTime string
+)
+
+type Info struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+	// Schema is the latest migration version of each database the binary
+	// was built with.
+	Schema map[string]int `json:"schema"`
+}
+
+// Get describes this build. Without an injected commit it falls back to the
+// revision the go tool stamps when building inside a git checkout.
+func Get(schema map[string]int) Info {
+	info := Info{
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Schema:    schema,
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+		if build, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range build.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+	}
+
+	return info
+}
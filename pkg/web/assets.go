@@ -0,0 +1,122 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// StaticPrefix is the URL path the static assets are served under.
+const StaticPrefix = "/static/"
+
+// Assets serves the files under static/. Each is published under a name
+// carrying a hash of its content, like css/site.3f2a9c1b.css, so browsers
+// can cache it for good and still fetch a new version the moment it changes.
+type Assets struct {
+	fsys fs.FS
+	// dev serves files straight from fsys under their plain names, so edits
+	// show up on the next reload.
+	dev bool
+	// hashed maps a file's name to its published name, and files the other
+	// way round.
+	hashed map[string]string
+	files  map[string]string
+}
+
+// NewAssets reads the static files in fsys, which is laid out like
+// Embedded(). With dev set, names aren't hashed and nothing is cached.
+func NewAssets(fsys fs.FS, dev bool) (*Assets, error) {
+	static, err := fs.Sub(fsys, "static")
+	if err != nil {
+		return nil, err
+	}
+
+	assets := &Assets{
+		fsys:   static,
+		dev:    dev,
+		hashed: map[string]string{},
+		files:  map[string]string{},
+	}
+	if dev {
+		return assets, nil
+	}
+
+	err = fs.WalkDir(static, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(static, name)
+		if err != nil {
+			return err
+		}
+		published := hashedName(name, data)
+		assets.hashed[name] = published
+		assets.files[published] = name
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("static assets: %w", err)
+	}
+
+	return assets, nil
+}
+
+// hashedName puts the first 8 bytes of the content's SHA-256 before the
+// extension.
+func hashedName(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:8]) + ext
+}
+
+// Path is the URL of the named static file, such as "css/site.css", for use
+// in templates. Naming a file that doesn't exist is an error, so a typo
+// fails the page rather than leaving a broken link.
+func (a *Assets) Path(name string) (string, error) {
+	if a.dev {
+		if _, err := fs.Stat(a.fsys, name); err != nil {
+			return "", fmt.Errorf("static asset %q: %w", name, err)
+		}
+		return StaticPrefix + name, nil
+	}
+
+	published, ok := a.hashed[name]
+	if !ok {
+		return "", fmt.Errorf("static asset %q does not exist", name)
+	}
+	return StaticPrefix + published, nil
+}
+
+// ServeHTTP serves a static file. Hashed names are cached for a year; plain
+// names, as requested in dev mode or by hand, must be revalidated.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requested := strings.TrimPrefix(r.URL.Path, StaticPrefix)
+
+	name, ok := a.files[requested]
+	if ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		name = requested
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	data, err := fs.ReadFile(a.fsys, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Embedded files have no modification time, so the ETag does the
+	// revalidation.
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
@@ -0,0 +1,90 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"food-review/pkg/web"
+)
+
+func TestAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/css/site.css": {Data: []byte("body { color: red }")},
+		"static/js/edit.js":   {Data: []byte("console.log(1)")},
+	}
+
+	t.Run("Hashed Names", func(t *testing.T) {
+		assets, err := web.NewAssets(fsys, false)
+		require.NoError(t, err)
+
+		path, err := assets.Path("css/site.css")
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`^/static/css/site\.[0-9a-f]{16}\.css$`), path)
+
+		resp := httptest.NewRecorder()
+		assets.ServeHTTP(resp, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "body { color: red }", resp.Body.String())
+		assert.True(t, strings.HasPrefix(resp.Header().Get("Content-Type"), "text/css"))
+		assert.Contains(t, resp.Header().Get("Cache-Control"), "immutable")
+
+		_, err = assets.Path("css/missing.css")
+		assert.Error(t, err)
+	})
+
+	t.Run("Content Changes Name", func(t *testing.T) {
+		before, err := web.NewAssets(fsys, false)
+		require.NoError(t, err)
+		after, err := web.NewAssets(fstest.MapFS{
+			"static/css/site.css": {Data: []byte("body { color: blue }")},
+		}, false)
+		require.NoError(t, err)
+
+		beforePath, _ := before.Path("css/site.css")
+		afterPath, _ := after.Path("css/site.css")
+		assert.NotEqual(t, beforePath, afterPath)
+	})
+
+	t.Run("Plain Names Revalidate", func(t *testing.T) {
+		assets, err := web.NewAssets(fsys, false)
+		require.NoError(t, err)
+
+		resp := httptest.NewRecorder()
+		assets.ServeHTTP(resp, httptest.NewRequest("GET", "/static/js/edit.js", nil))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "no-cache", resp.Header().Get("Cache-Control"))
+
+		resp = httptest.NewRecorder()
+		assets.ServeHTTP(resp, httptest.NewRequest("GET", "/static/js/missing.js", nil))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("Dev Mode", func(t *testing.T) {
+		assets, err := web.NewAssets(fsys, true)
+		require.NoError(t, err)
+
+		path, err := assets.Path("js/edit.js")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/static/js/edit.js", path)
+		}
+		_, err = assets.Path("js/missing.js")
+		assert.Error(t, err)
+	})
+}
+
+func TestEmbedded(t *testing.T) {
+	assets, err := web.NewAssets(web.Embedded(), false)
+	require.NoError(t, err)
+
+	for _, name := range []string{"css/site.css", "js/edit.js", "img/logo.svg"} {
+		_, err := assets.Path(name)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,60 @@
+// Saves the edit form with a PUT to the review's URL. The edit waits for a
+// moderator, so the review is hidden until then and the list is shown instead.
+document.addEventListener("DOMContentLoaded", function () {
+    var form = document.getElementById("review-form");
+    if (!form) {
+        return;
+    }
+
+    form.addEventListener("submit", function (event) {
+        event.preventDefault();
+
+        var url = form.dataset.reviewUrl;
+        var payload = {
+            review: form.elements["content"].value
+        };
+
+        fetch(url, { method: "PUT", body: JSON.stringify(payload) })
+            .then(function (response) {
+                if (response.ok) {
+                    flash.next(form.dataset.savedMessage);
+                    window.location = form.dataset.savedUrl;
+                } else {
+                    return response.text().then(function (message) {
+                        window.alert(message);
+                    });
+                }
+            });
+    });
+});
+
+// Shows the review as it will look once saved while it's being written. The
+// server renders the preview so it matches the saved review exactly.
+document.addEventListener("DOMContentLoaded", function () {
+    var form = document.getElementById("review-form");
+    var preview = document.getElementById("preview");
+    if (!form || !preview) {
+        return;
+    }
+
+    var timer = null;
+    var latest = 0;
+    form.elements["content"].addEventListener("input", function () {
+        window.clearTimeout(timer);
+        timer = window.setTimeout(function () {
+            var request = ++latest;
+            var payload = { review: form.elements["content"].value };
+
+            fetch(preview.dataset.previewUrl, { method: "POST", body: JSON.stringify(payload) })
+                .then(function (response) {
+                    return response.ok ? response.text() : null;
+                })
+                .then(function (html) {
+                    // A slow response mustn't overwrite a newer one.
+                    if (html !== null && request === latest) {
+                        preview.innerHTML = html;
+                    }
+                });
+        }, 300);
+    });
+});
@@ -0,0 +1,38 @@
+// Shows a one-off message in the page's flash area. Pages are cached and
+// shared, so the message travels in sessionStorage instead: flash.next keeps
+// it for the page the browser is about to load, flash.show displays it now.
+var flash = (function () {
+    var key = "flash";
+
+    function show(message) {
+        var area = document.getElementById("flash");
+        if (!area) {
+            return;
+        }
+        area.textContent = message;
+        area.hidden = false;
+    }
+
+    function next(message) {
+        try {
+            window.sessionStorage.setItem(key, message);
+        } catch (e) {
+            // Private browsing can refuse storage; the message is only a nicety.
+        }
+    }
+
+    document.addEventListener("DOMContentLoaded", function () {
+        var message = null;
+        try {
+            message = window.sessionStorage.getItem(key);
+            window.sessionStorage.removeItem(key);
+        } catch (e) {
+            return;
+        }
+        if (message) {
+            show(message);
+        }
+    });
+
+    return { show: show, next: next };
+})();
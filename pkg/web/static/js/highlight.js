@@ -0,0 +1,37 @@
+// Marks the searched keyword in the reviews of an element with a
+// data-highlight attribute.
+document.addEventListener("DOMContentLoaded", function () {
+    document.querySelectorAll("[data-highlight]").forEach(function (container) {
+        var keyword = container.dataset.highlight;
+        if (!keyword) {
+            return;
+        }
+        var escaped = keyword.replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
+        var pattern = new RegExp("(" + escaped + ")", "gi");
+
+        container.querySelectorAll(".review-content").forEach(function (content) {
+            var walker = document.createTreeWalker(content, NodeFilter.SHOW_TEXT);
+            var nodes = [];
+            while (walker.nextNode()) {
+                nodes.push(walker.currentNode);
+            }
+            nodes.forEach(function (node) {
+                var parts = node.nodeValue.split(pattern);
+                if (parts.length === 1) {
+                    return;
+                }
+                var fragment = document.createDocumentFragment();
+                parts.forEach(function (part, i) {
+                    if (i % 2 === 1) {
+                        var mark = document.createElement("mark");
+                        mark.textContent = part;
+                        fragment.appendChild(mark);
+                    } else if (part) {
+                        fragment.appendChild(document.createTextNode(part));
+                    }
+                });
+                node.parentNode.replaceChild(fragment, node);
+            });
+        });
+    });
+});
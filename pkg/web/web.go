@@ -0,0 +1,23 @@
+// Package web holds the HTML templates and static assets, embedded in the
+// binary so the server doesn't depend on its working directory.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed template static
+var embedded embed.FS
+
+// TemplatePattern matches the page templates within the assets.
+const TemplatePattern = "template/*.html"
+
+// SharedTemplatePatterns match the layouts and partials every page can use.
+var SharedTemplatePatterns = []string{"template/layout/*.html", "template/partials/*.html"}
+
+// Embedded returns the assets compiled into the binary, laid out as in this
+// package's directory: template/ and static/.
+func Embedded() fs.FS {
+	return embedded
+}